@@ -347,31 +347,13 @@ func MakeFactoidAddressFromKoinify(mnemonic string) (*FactoidAddress, error) {
 }
 
 func MakeBIP44FactoidAddress(mnemonic string, account, chain, address uint32) (*FactoidAddress, error) {
-	mnemonic, err := ParseAndValidateMnemonic(mnemonic)
-	if err != nil {
-		return nil, err
-	}
-
-	child, err := bip44.NewKeyFromMnemonic(mnemonic, bip44.TypeFactomFactoids, account, chain, address)
-	if err != nil {
-		return nil, err
-	}
-
-	return MakeFactoidAddress(child.Key)
+	path := &DerivationPath{bip44.TypeFactomFactoids, account, chain, address}
+	return MakeFactoidAddressFromPath(mnemonic, "", path)
 }
 
 func MakeBIP44ECAddress(mnemonic string, account, chain, address uint32) (*ECAddress, error) {
-	mnemonic, err := ParseAndValidateMnemonic(mnemonic)
-	if err != nil {
-		return nil, err
-	}
-
-	child, err := bip44.NewKeyFromMnemonic(mnemonic, bip44.TypeFactomEntryCredits, account, chain, address)
-	if err != nil {
-		return nil, err
-	}
-
-	return MakeECAddress(child.Key)
+	path := &DerivationPath{bip44.TypeFactomEntryCredits, account, chain, address}
+	return MakeECAddressFromPath(mnemonic, "", path)
 }
 
 func (a *FactoidAddress) RCDHash() []byte {
@@ -411,17 +393,5 @@ func (a *FactoidAddress) SecString() string {
 }
 
 func (a *FactoidAddress) String() string {
-	buf := new(bytes.Buffer)
-
-	// FC address prefix
-	buf.Write(fcPubPrefix)
-
-	// RCD Hash
-	buf.Write(a.RCDHash())
-
-	// Checksum
-	check := shad(buf.Bytes())[:ChecksumLength]
-	buf.Write(check)
-
-	return base58.Encode(buf.Bytes())
+	return fctAddressString(a.RCDHash())
 }
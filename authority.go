@@ -0,0 +1,361 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	ed "github.com/FactomProject/ed25519"
+)
+
+// Authority node identities use their own entry format, separate from the
+// generic "IdentityChain" identities. The root identity chain holds the four
+// identity key hashes and registers a management subchain, and the signing,
+// anchor, and coinbase configuration of the node is published in signed
+// entries with a timestamp, the latest of which is in effect.
+//
+// Every signed entry ends with a preimage (0x01 | ed25519 public key) and the
+// signature of the preceding ExtIDs. The sha256d of the preimage must be the
+// first identity key of the root identity chain.
+
+// AuthorityIdentityRegisterChain is the chain authority identities are
+// registered in.
+const AuthorityIdentityRegisterChain = "888888001750ede0eff4b05f0c3f557890b256450cabbb84cada937f9c258327"
+
+// Authority entry types, the second ExtID of the entries.
+const (
+	AuthorityIdentityChain           = "Identity Chain"
+	AuthorityManagementChain         = "Server Management"
+	AuthorityRegisterIdentity        = "Register Factom Identity"
+	AuthorityRegisterManagementChain = "Register Server Management"
+	AuthorityBlockSigningKey         = "New Block Signing Key"
+	AuthorityBTCKey                  = "New Bitcoin Key"
+	AuthorityMatryoshkaHash          = "New Matryoshka Hash"
+	AuthorityCoinbaseAddress         = "Coinbase Address"
+	AuthorityEfficiency              = "Server Efficiency"
+)
+
+// authorityEntryLengths are the ExtID lengths of the signed authority entries,
+// without the preimage and signature. A length of -1 is not checked.
+var authorityEntryLengths = map[string][]int{
+	AuthorityRegisterIdentity:        {1, -1, 32},
+	AuthorityRegisterManagementChain: {1, -1, 32},
+	AuthorityBlockSigningKey:         {1, -1, 32, 32, 8},
+	AuthorityBTCKey:                  {1, -1, 32, 1, 1, 20, 8},
+	AuthorityMatryoshkaHash:          {1, -1, 32, 32, 8},
+	AuthorityCoinbaseAddress:         {1, -1, 32, 32, 8},
+	AuthorityEfficiency:              {1, -1, 32, 2, 8},
+}
+
+const authorityPreimageLength = 1 + ed.PublicKeySize
+
+// AuthorityEntry is a parsed authority identity entry. Only the fields for the
+// entry type are set.
+type AuthorityEntry struct {
+	Type      string `json:"type"`
+	EntryHash string `json:"entryhash,omitempty"`
+	Height    int64  `json:"height,omitempty"`
+	ChainID   string `json:"chainid"`
+
+	// IdentityChainID is the root identity chain the entry is for
+	IdentityChainID string `json:"identitychainid"`
+	// SubchainID is the registered management chain
+	SubchainID string `json:"subchainid,omitempty"`
+	// IdentityKeys are the four identity key hashes of a root identity chain
+	IdentityKeys []string `json:"identitykeys,omitempty"`
+	// Key is the block signing key, the bitcoin key, the Matryoshka hash, or
+	// the coinbase address
+	Key         string `json:"key,omitempty"`
+	BTCKeyLevel byte   `json:"btckeylevel,omitempty"`
+	BTCKeyType  byte   `json:"btckeytype,omitempty"`
+	Efficiency  uint16 `json:"efficiency,omitempty"`
+	Timestamp   int64  `json:"timestamp,omitempty"`
+
+	// SignerKey is the public key of the preimage of a signed entry, and
+	// SignerKeyHash its sha256d
+	SignerKey     string `json:"signerkey,omitempty"`
+	SignerKeyHash string `json:"signerkeyhash,omitempty"`
+	// SignatureValid is true if the signature of a signed entry verifies
+	SignatureValid bool `json:"signaturevalid"`
+
+	// Valid and Reason are set by GetAuthorityIdentity
+	Valid  bool   `json:"valid"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ParseAuthorityEntry parses an authority identity entry and verifies the
+// signature of signed entries. It does not check that the signer key belongs
+// to the identity.
+func ParseAuthorityEntry(e *Entry) (*AuthorityEntry, error) {
+	if len(e.ExtIDs) < 3 || !bytes.Equal(e.ExtIDs[0], []byte{0x00}) {
+		return nil, fmt.Errorf("not an authority identity entry")
+	}
+
+	a := new(AuthorityEntry)
+	a.Type = string(e.ExtIDs[1])
+	a.ChainID = e.ChainID
+
+	switch a.Type {
+	case AuthorityIdentityChain:
+		if len(e.ExtIDs) < 6 {
+			return nil, fmt.Errorf("invalid %s entry", a.Type)
+		}
+		for _, k := range e.ExtIDs[2:6] {
+			if len(k) != 32 {
+				return nil, fmt.Errorf("invalid %s entry", a.Type)
+			}
+			a.IdentityKeys = append(a.IdentityKeys, hex.EncodeToString(k))
+		}
+		a.IdentityChainID = e.ChainID
+		return a, nil
+	case AuthorityManagementChain:
+		if len(e.ExtIDs[2]) != 32 {
+			return nil, fmt.Errorf("invalid %s entry", a.Type)
+		}
+		a.IdentityChainID = hex.EncodeToString(e.ExtIDs[2])
+		a.SubchainID = e.ChainID
+		return a, nil
+	}
+
+	lengths, ok := authorityEntryLengths[a.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown authority entry type %s", a.Type)
+	}
+	if len(e.ExtIDs) != len(lengths)+2 {
+		return nil, fmt.Errorf("invalid %s entry", a.Type)
+	}
+	for i, l := range lengths {
+		if l >= 0 && len(e.ExtIDs[i]) != l {
+			return nil, fmt.Errorf("invalid %s entry", a.Type)
+		}
+	}
+
+	preimage := e.ExtIDs[len(lengths)]
+	sig := e.ExtIDs[len(lengths)+1]
+	if len(preimage) != authorityPreimageLength || preimage[0] != 0x01 || len(sig) != ed.SignatureSize {
+		return nil, fmt.Errorf("invalid %s entry signature", a.Type)
+	}
+	a.SignerKey = hex.EncodeToString(preimage[1:])
+	a.SignerKeyHash = hex.EncodeToString(shad(preimage))
+
+	var pub [ed.PublicKeySize]byte
+	copy(pub[:], preimage[1:])
+	var signature [ed.SignatureSize]byte
+	copy(signature[:], sig)
+	a.SignatureValid = ed.Verify(&pub, bytes.Join(e.ExtIDs[:len(lengths)], nil), &signature)
+
+	ids := e.ExtIDs
+	switch a.Type {
+	case AuthorityRegisterIdentity:
+		a.IdentityChainID = hex.EncodeToString(ids[2])
+	case AuthorityRegisterManagementChain:
+		a.IdentityChainID = e.ChainID
+		a.SubchainID = hex.EncodeToString(ids[2])
+	case AuthorityBlockSigningKey, AuthorityMatryoshkaHash:
+		a.IdentityChainID = hex.EncodeToString(ids[2])
+		a.Key = hex.EncodeToString(ids[3])
+		a.Timestamp = int64(binary.BigEndian.Uint64(ids[4]))
+	case AuthorityCoinbaseAddress:
+		a.IdentityChainID = hex.EncodeToString(ids[2])
+		a.Key = fctAddressString(ids[3])
+		a.Timestamp = int64(binary.BigEndian.Uint64(ids[4]))
+	case AuthorityEfficiency:
+		a.IdentityChainID = hex.EncodeToString(ids[2])
+		a.Efficiency = binary.BigEndian.Uint16(ids[3])
+		a.Timestamp = int64(binary.BigEndian.Uint64(ids[4]))
+	case AuthorityBTCKey:
+		a.IdentityChainID = hex.EncodeToString(ids[2])
+		a.BTCKeyLevel = ids[3][0]
+		a.BTCKeyType = ids[4][0]
+		a.Key = hex.EncodeToString(ids[5])
+		a.Timestamp = int64(binary.BigEndian.Uint64(ids[6]))
+	}
+
+	return a, nil
+}
+
+// AuthorityAnchorKey is a bitcoin anchor key of an authority node. Type 0 is
+// a P2PKH key hash and type 1 a P2SH script hash.
+type AuthorityAnchorKey struct {
+	Level byte   `json:"level"`
+	Type  byte   `json:"type"`
+	Key   string `json:"key"`
+}
+
+// AuthorityIdentity is the configuration of an authority node identity.
+type AuthorityIdentity struct {
+	ChainID           string                `json:"chainid"`
+	IdentityKeys      []string              `json:"identitykeys"`
+	ManagementChainID string                `json:"managementchainid"`
+	BlockSigningKey   string                `json:"blocksigningkey"`
+	BTCKeys           []*AuthorityAnchorKey `json:"btckeys"`
+	MatryoshkaHash    string                `json:"matryoshkahash"`
+	CoinbaseAddress   string                `json:"coinbaseaddress"`
+	// Efficiency is the share of the coinbase kept by the grant pool, in
+	// hundredths of a percent
+	Efficiency uint16 `json:"efficiency"`
+	Height     int64  `json:"height"`
+
+	// Entries are the authority entries of the root and management chains,
+	// including the invalid ones, in chain order
+	Entries []*AuthorityEntry `json:"entries"`
+}
+
+// GetAuthorityIdentity resolves the configuration of the authority node
+// identity with the root identity chain chainID at the block height. For each
+// setting the valid entry with the latest timestamp is in effect.
+func GetAuthorityIdentity(chainID string, height int64) (*AuthorityIdentity, error) {
+	entries, err := getChainEntriesWithHeights(chainID, height)
+	if err != nil {
+		return nil, err
+	} else if len(entries) == 0 {
+		return nil, fmt.Errorf("chain did not yet exist at height %d", height)
+	}
+
+	first, err := ParseAuthorityEntry(entries[0].Entry)
+	if err != nil || first.Type != AuthorityIdentityChain {
+		return nil, fmt.Errorf("no authority identity found at chain ID: %s", chainID)
+	}
+
+	id := new(AuthorityIdentity)
+	id.ChainID = chainID
+	id.IdentityKeys = first.IdentityKeys
+	id.Height = height
+	id.BTCKeys = make([]*AuthorityAnchorKey, 0)
+	id.Entries = make([]*AuthorityEntry, 0)
+
+	r := &authorityResolver{id: id, timestamps: make(map[string]int64)}
+	for _, e := range entries[1:] {
+		r.apply(e)
+	}
+
+	if id.ManagementChainID != "" {
+		sub, err := getChainEntriesWithHeights(id.ManagementChainID, height)
+		if err != nil {
+			return nil, err
+		}
+		for i, e := range sub {
+			if i == 0 {
+				a, err := ParseAuthorityEntry(e.Entry)
+				if err != nil || a.Type != AuthorityManagementChain || a.IdentityChainID != chainID {
+					return nil, fmt.Errorf("management chain %s does not belong to %s", id.ManagementChainID, chainID)
+				}
+				continue
+			}
+			r.apply(e)
+		}
+	}
+
+	return id, nil
+}
+
+type authorityResolver struct {
+	id         *AuthorityIdentity
+	timestamps map[string]int64
+}
+
+// apply validates an authority entry and applies it to the configuration if
+// it is newer than the setting in effect.
+func (r *authorityResolver) apply(e *heightEntry) {
+	a, err := ParseAuthorityEntry(e.Entry)
+	if err != nil {
+		return
+	}
+	a.EntryHash = e.Hash
+	a.Height = e.Height
+	r.id.Entries = append(r.id.Entries, a)
+
+	switch {
+	case a.IdentityChainID != r.id.ChainID:
+		a.Reason = "entry is for another identity"
+		return
+	case a.Type == AuthorityIdentityChain || a.Type == AuthorityManagementChain || a.Type == AuthorityRegisterIdentity:
+		a.Reason = "entry does not belong in an identity chain"
+		return
+	case !a.SignatureValid:
+		a.Reason = "invalid signature"
+		return
+	case a.SignerKeyHash != r.id.IdentityKeys[0]:
+		a.Reason = "not signed by the first identity key"
+		return
+	}
+
+	if a.Type == AuthorityRegisterManagementChain {
+		if e.ChainID != r.id.ChainID {
+			a.Reason = "management chain registered outside the root identity chain"
+			return
+		}
+		if r.id.ManagementChainID != "" {
+			a.Reason = "management chain already registered"
+			return
+		}
+		a.Valid = true
+		r.id.ManagementChainID = a.SubchainID
+		return
+	}
+
+	a.Valid = true
+	setting := a.Type
+	if a.Type == AuthorityBTCKey {
+		setting = fmt.Sprintf("%s %d", a.Type, a.BTCKeyLevel)
+	}
+	if ts, ok := r.timestamps[setting]; ok && a.Timestamp <= ts {
+		a.Reason = "superseded by a newer entry"
+		return
+	}
+	r.timestamps[setting] = a.Timestamp
+
+	switch a.Type {
+	case AuthorityBlockSigningKey:
+		r.id.BlockSigningKey = a.Key
+	case AuthorityMatryoshkaHash:
+		r.id.MatryoshkaHash = a.Key
+	case AuthorityCoinbaseAddress:
+		r.id.CoinbaseAddress = a.Key
+	case AuthorityEfficiency:
+		r.id.Efficiency = a.Efficiency
+	case AuthorityBTCKey:
+		k := &AuthorityAnchorKey{Level: a.BTCKeyLevel, Type: a.BTCKeyType, Key: a.Key}
+		for i, b := range r.id.BTCKeys {
+			if b.Level == k.Level {
+				r.id.BTCKeys[i] = k
+				return
+			}
+		}
+		r.id.BTCKeys = append(r.id.BTCKeys, k)
+	}
+}
+
+// GetAuthorityIdentityRegistration returns the valid registration of the
+// authority identity in AuthorityIdentityRegisterChain, or nil if it is not
+// registered.
+func GetAuthorityIdentityRegistration(chainID string) (*AuthorityEntry, error) {
+	id, err := GetAuthorityIdentity(chainID, math.MaxInt64)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := getChainEntriesWithHeights(AuthorityIdentityRegisterChain, math.MaxInt64)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		a, err := ParseAuthorityEntry(e.Entry)
+		if err != nil || a.Type != AuthorityRegisterIdentity || a.IdentityChainID != chainID {
+			continue
+		}
+		if a.SignatureValid && a.SignerKeyHash == id.IdentityKeys[0] {
+			a.EntryHash = e.Hash
+			a.Height = e.Height
+			a.Valid = true
+			return a, nil
+		}
+	}
+	return nil, nil
+}
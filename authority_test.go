@@ -0,0 +1,118 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func authorityKeyHash(k *IdentityKey) []byte {
+	h := sha256.Sum256(append([]byte{0x01}, k.PubBytes()...))
+	h = sha256.Sum256(h[:])
+	return h[:]
+}
+
+func authorityTimestamp(ts uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, ts)
+	return b
+}
+
+// signedAuthorityEntry appends the preimage and signature of the ExtIDs.
+func signedAuthorityEntry(chainID string, k *IdentityKey, ids ...[]byte) *Entry {
+	sig := k.Sign(bytes.Join(ids, nil))
+	ids = append(ids, append([]byte{0x01}, k.PubBytes()...), sig[:])
+	return &Entry{ChainID: chainID, ExtIDs: ids}
+}
+
+func TestGetAuthorityIdentity(t *testing.T) {
+	k := testIdentityKeys(t)
+	f := newFakeFactomd()
+
+	root := NewChain(&Entry{ExtIDs: [][]byte{
+		{0x00}, []byte(AuthorityIdentityChain),
+		authorityKeyHash(k[0]), authorityKeyHash(k[1]), authorityKeyHash(k[2]), authorityKeyHash(k[3]),
+		[]byte("nonce"),
+	}})
+	rootID, _ := hex.DecodeString(root.ChainID)
+	mgmt := NewChain(&Entry{ExtIDs: [][]byte{{0x00}, []byte(AuthorityManagementChain), rootID, []byte("nonce")}})
+	mgmtID, _ := hex.DecodeString(mgmt.ChainID)
+	f.addBlock(10, root.FirstEntry)
+	f.addBlock(10, mgmt.FirstEntry)
+
+	signingKey := bytes.Repeat([]byte{0xaa}, 32)
+	coinbase := bytes.Repeat([]byte{0xbb}, 32)
+	efficiency := func(e uint16, ts uint64) *Entry {
+		b := make([]byte, 2)
+		binary.BigEndian.PutUint16(b, e)
+		return signedAuthorityEntry(root.ChainID, k[0], []byte{0x00}, []byte(AuthorityEfficiency), rootID, b, authorityTimestamp(ts))
+	}
+	f.addBlock(20,
+		signedAuthorityEntry(root.ChainID, k[0], []byte{0x00}, []byte(AuthorityRegisterManagementChain), mgmtID),
+		signedAuthorityEntry(root.ChainID, k[0], []byte{0x00}, []byte(AuthorityCoinbaseAddress), rootID, coinbase, authorityTimestamp(100)),
+		efficiency(5000, 200),
+		efficiency(1000, 150),
+	)
+	f.addBlock(20,
+		signedAuthorityEntry(mgmt.ChainID, k[0], []byte{0x00}, []byte(AuthorityBlockSigningKey), rootID, signingKey, authorityTimestamp(100)),
+		signedAuthorityEntry(mgmt.ChainID, k[1], []byte{0x00}, []byte(AuthorityBlockSigningKey), rootID, bytes.Repeat([]byte{0xcc}, 32), authorityTimestamp(300)),
+		signedAuthorityEntry(mgmt.ChainID, k[0], []byte{0x00}, []byte(AuthorityBTCKey), rootID, []byte{0}, []byte{0}, bytes.Repeat([]byte{0xdd}, 20), authorityTimestamp(100)),
+	)
+
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	SetFactomdServer(ts.URL[7:])
+
+	id, err := GetAuthorityIdentity(root.ChainID, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id.IdentityKeys) != 4 || id.IdentityKeys[0] != hex.EncodeToString(authorityKeyHash(k[0])) {
+		t.Errorf("unexpected identity keys %v", id.IdentityKeys)
+	}
+	if id.ManagementChainID != mgmt.ChainID {
+		t.Errorf("expected management chain %s, found %s", mgmt.ChainID, id.ManagementChainID)
+	}
+	if id.BlockSigningKey != hex.EncodeToString(signingKey) {
+		t.Errorf("expected block signing key %x, found %s", signingKey, id.BlockSigningKey)
+	}
+	if id.Efficiency != 5000 {
+		t.Errorf("expected the efficiency with the latest timestamp, found %d", id.Efficiency)
+	}
+	if id.CoinbaseAddress == "" || AddressStringType(id.CoinbaseAddress) != FactoidPub {
+		t.Errorf("unexpected coinbase address %s", id.CoinbaseAddress)
+	}
+	if len(id.BTCKeys) != 1 || id.BTCKeys[0].Key != hex.EncodeToString(bytes.Repeat([]byte{0xdd}, 20)) {
+		t.Errorf("unexpected bitcoin keys %v", id.BTCKeys)
+	}
+
+	var invalid int
+	for _, e := range id.Entries {
+		if !e.Valid {
+			invalid++
+			if e.Reason != "not signed by the first identity key" {
+				t.Errorf("unexpected invalid entry %+v", e)
+			}
+		}
+	}
+	if len(id.Entries) != 7 || invalid != 1 {
+		t.Errorf("expected 7 entries with 1 invalid, found %d with %d invalid", len(id.Entries), invalid)
+	}
+
+	id, err = GetAuthorityIdentity(root.ChainID, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.ManagementChainID != "" || id.BlockSigningKey != "" {
+		t.Errorf("expected no configuration at height 15")
+	}
+}
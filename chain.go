@@ -9,6 +9,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"time"
 )
 
 type Chain struct {
@@ -46,13 +47,34 @@ func ChainExists(chainid string) bool {
 // factomd web api. The request includes the marshaled MessageRequest with the
 // Entry Credit Signature.
 func ComposeChainCommit(c *Chain, ec *ECAddress) (*JSON2Request, error) {
+	return ComposeChainCommitAt(c, ec, time.Now())
+}
+
+// ComposeChainCommitAt creates a JSON2Request to commit a new Chain with the
+// commit timestamp ts. The same Chain, Entry Credit address, and timestamp
+// always produce the same commit. ts is not checked against the clock:
+// factomd rejects commits submitted more than CommitWindow away from ts, so
+// the caller must check ts with ValidateCommitTime before submitting the
+// commit.
+func ComposeChainCommitAt(c *Chain, ec *ECAddress, ts time.Time) (*JSON2Request, error) {
+	return ComposeChainCommitWithSigner(c, ec.Signer(), ts)
+}
+
+// ComposeChainCommitWithSigner creates a JSON2Request to commit a new Chain
+// with the commit timestamp ts, paid for by the Entry Credit key of s. As with
+// ComposeChainCommitAt, the caller must check ts with ValidateCommitTime.
+func ComposeChainCommitWithSigner(c *Chain, s Signer, ts time.Time) (*JSON2Request, error) {
 	buf := new(bytes.Buffer)
 
 	// 1 byte version
 	buf.Write([]byte{0})
 
 	// 6 byte milliTimestamp
-	buf.Write(milliTime())
+	if t, err := milliTimestamp(ts); err != nil {
+		return nil, err
+	} else {
+		buf.Write(t)
+	}
 
 	e := c.FirstEntry
 
@@ -83,9 +105,12 @@ func ComposeChainCommit(c *Chain, ec *ECAddress) (*JSON2Request, error) {
 	}
 
 	// 32 byte Entry Credit Address Public Key + 64 byte Signature
-	sig := ec.Sign(buf.Bytes())
-	buf.Write(ec.PubBytes())
-	buf.Write(sig[:])
+	sig, err := signWith(s, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(s.PubBytes())
+	buf.Write(sig)
 
 	params := messageRequest{Message: hex.EncodeToString(buf.Bytes())}
 	req := NewJSON2Request("commit-chain", APICounter(), params)
@@ -111,12 +136,18 @@ func ComposeChainReveal(c *Chain) (*JSON2Request, error) {
 // network is commited to publishing the Chain it may be published by revealing
 // the First Entry in the Chain.
 func CommitChain(c *Chain, ec *ECAddress) (string, error) {
+	return CommitChainWithSigner(c, ec.Signer())
+}
+
+// CommitChainWithSigner commits the Chain like CommitChain, with the Entry
+// Credit key held by s.
+func CommitChainWithSigner(c *Chain, s Signer) (string, error) {
 	type commitResponse struct {
 		Message string `json:"message"`
 		TxID    string `json:"txid"`
 	}
 
-	req, err := ComposeChainCommit(c, ec)
+	req, err := ComposeChainCommitWithSigner(c, s, time.Now())
 	if err != nil {
 		return "", err
 	}
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	. "github.com/FactomProject/factom"
 )
@@ -122,6 +123,39 @@ func TestComposeChainCommit(t *testing.T) {
 	}
 }
 
+func TestComposeChainCommitAt(t *testing.T) {
+	type response struct {
+		Message string `json:"message"`
+	}
+	ecAddr, _ := GetECAddress("Es2Rf7iM6PdsqfYCo3D1tnAR65SkLENyWJG1deUzpRMQmbh9F3eG")
+	ent := new(Entry)
+	ent.ChainID = "954d5a49fd70d9b8bcdb35d252267829957f7ef7fa6c74f88419bdc5e82209f4"
+	ent.Content = []byte("test!")
+	ent.ExtIDs = append(ent.ExtIDs, []byte("test"))
+	newChain := NewChain(ent)
+
+	ts := time.Unix(1500000000, 123e6)
+	c1, err := ComposeChainCommitAt(newChain, ecAddr, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := ComposeChainCommitAt(newChain, ecAddr, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r1 := new(response)
+	json.Unmarshal(c1.Params, r1)
+	r2 := new(response)
+	json.Unmarshal(c2.Params, r2)
+
+	if r1.Message != r2.Message {
+		t.Errorf("commits with the same timestamp differ: %s %s", r1.Message, r2.Message)
+	}
+	if r1.Message[2:14] != "015d3ef7987b" {
+		t.Errorf("unexpected timestamp %s", r1.Message[2:14])
+	}
+}
+
 func TestComposeChainReveal(t *testing.T) {
 
 	ent := new(Entry)
@@ -0,0 +1,363 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/FactomProject/btcutil/base58"
+	ed "github.com/FactomProject/ed25519"
+)
+
+// A credential is a JWT (RFC 7519) signed with an Identity Key using the EdDSA
+// JWS algorithm (RFC 8037). The issuer is the did:factom DID of the identity
+// chain and the key id is the DID of the Identity Key, as in the DID document
+// of the identity.
+//
+// A credential is valid if the key is active for the issuer identity at the
+// current block height. The Height claim made by the issuer can be backdated,
+// so a credential signed by a key that has since been replaced is only valid
+// if it is anchored: the key is then checked at the height of an anchor entry
+// holding the credential hash, which cannot be backdated.
+
+// CredentialClaims are the claims of a credential. The W3C verifiable
+// credential, if any, is held in Credential.
+type CredentialClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub,omitempty"`
+	ID        string `json:"jti,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	// Height is the block height the credential was issued at
+	Height     int64           `json:"height"`
+	Credential json.RawMessage `json:"vc,omitempty"`
+}
+
+// Expired returns true if the credential is expired or not yet valid at t.
+func (c *CredentialClaims) Expired(t time.Time) bool {
+	if c.ExpiresAt != 0 && t.Unix() >= c.ExpiresAt {
+		return true
+	}
+	return c.NotBefore != 0 && t.Unix() < c.NotBefore
+}
+
+type credentialHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// Credential is a parsed credential whose signature has not been checked
+// against the issuer identity.
+type Credential struct {
+	Claims        *CredentialClaims
+	IssuerChainID string
+	SignerKey     string
+
+	signingInput []byte
+	signature    []byte
+}
+
+// IssueCredential signs the claims with the Identity Key of the issuer
+// identity and returns the credential. The issuer, the issue time, if it is
+// unset, and the block height of issuance are set in the claims.
+func IssueCredential(claims *CredentialClaims, issuerChainID string, height int64, key *IdentityKey) (string, error) {
+	return IssueCredentialWithSigner(claims, issuerChainID, height, key.Signer())
+}
+
+// IssueCredentialWithSigner issues the credential like IssueCredential, signed
+// by the Identity Key held by signer.
+func IssueCredentialWithSigner(claims *CredentialClaims, issuerChainID string, height int64, signer Signer) (string, error) {
+	did := DIDFromChainID(issuerChainID)
+	if _, err := ParseDID(did); err != nil {
+		return "", err
+	}
+
+	claims.Issuer = did
+	claims.Height = height
+	if claims.IssuedAt == 0 {
+		claims.IssuedAt = time.Now().Unix()
+	}
+
+	header := credentialHeader{
+		Alg: "EdDSA",
+		Typ: "JWT",
+		Kid: did + "#" + SignerIdentityKey(signer),
+	}
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	p, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(p)
+	sig, err := signWith(signer, []byte(input))
+	if err != nil {
+		return "", err
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// ParseCredential decodes a credential without verifying it.
+func ParseCredential(token string) (*Credential, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid credential")
+	}
+
+	h, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential header: %s", err)
+	}
+	header := new(credentialHeader)
+	if err := json.Unmarshal(h, header); err != nil {
+		return nil, fmt.Errorf("invalid credential header: %s", err)
+	}
+	if header.Alg != "EdDSA" {
+		return nil, fmt.Errorf("unsupported credential algorithm %s", header.Alg)
+	}
+
+	p, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential claims: %s", err)
+	}
+	c := new(Credential)
+	c.Claims = new(CredentialClaims)
+	if err := json.Unmarshal(p, c.Claims); err != nil {
+		return nil, fmt.Errorf("invalid credential claims: %s", err)
+	}
+
+	c.signature, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential signature: %s", err)
+	}
+	c.signingInput = []byte(parts[0] + "." + parts[1])
+
+	// the key id is the DID of the Identity Key
+	i := strings.Index(header.Kid, "#")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid credential key id %s", header.Kid)
+	}
+	if header.Kid[:i] != c.Claims.Issuer {
+		return nil, fmt.Errorf("credential key id %s is not a key of the issuer", header.Kid)
+	}
+	c.IssuerChainID, err = ParseDID(c.Claims.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	c.SignerKey = header.Kid[i+1:]
+	if IdentityKeyStringType(c.SignerKey) != IDPub {
+		return nil, fmt.Errorf("invalid credential key id %s", header.Kid)
+	}
+
+	return c, nil
+}
+
+// Hash returns the credential hash that is anchored on chain.
+func (c *Credential) Hash() []byte {
+	h := sha256.New()
+	h.Write(c.signingInput)
+	h.Write([]byte("."))
+	h.Write([]byte(base64.RawURLEncoding.EncodeToString(c.signature)))
+	return h.Sum(nil)
+}
+
+// verifySignature returns true if the signature is valid for the signer key.
+func (c *Credential) verifySignature() bool {
+	if len(c.signature) != ed.SignatureSize {
+		return false
+	}
+	var pub [ed.PublicKeySize]byte
+	copy(pub[:], base58.Decode(c.SignerKey)[IDKeyPrefixLength:IDKeyBodyLength])
+	var sig [ed.SignatureSize]byte
+	copy(sig[:], c.signature)
+	return ed.Verify(&pub, c.signingInput, &sig)
+}
+
+// VerifyCredential verifies the signature of a credential and that the signer
+// key is active for the issuer identity at the current block height. The
+// Height claimed by the issuer is not trusted.
+// An error is returned if the credential is malformed or expired, or if the
+// issuer identity could not be resolved.
+func VerifyCredential(token string) (*Credential, *IdentityEntryVerdict, error) {
+	return verifyCredential(token, "", fetchIdentityKeys)
+}
+
+// VerifyAnchoredCredential verifies the credential like VerifyCredential, but
+// the signer key is checked at the height of the anchor entry created by
+// NewCredentialAnchorEntry, which must hold the credential hash and be signed
+// by an active key of the issuer.
+func VerifyAnchoredCredential(token, anchorEntryHash string) (*Credential, *IdentityEntryVerdict, error) {
+	return verifyCredential(token, anchorEntryHash, fetchIdentityKeys)
+}
+
+// VerifyCredential is VerifyCredential with the issuer identity resolved
+// through the cache.
+func (c *IdentityKeyCache) VerifyCredential(token string) (*Credential, *IdentityEntryVerdict, error) {
+	return verifyCredential(token, "", c.keysAt)
+}
+
+// VerifyAnchoredCredential is VerifyAnchoredCredential with the issuer
+// identity resolved through the cache.
+func (c *IdentityKeyCache) VerifyAnchoredCredential(token, anchorEntryHash string) (*Credential, *IdentityEntryVerdict, error) {
+	return verifyCredential(token, anchorEntryHash, c.keysAt)
+}
+
+func verifyCredential(token, anchorEntryHash string, keysAt identityKeysFunc) (*Credential, *IdentityEntryVerdict, error) {
+	c, err := ParseCredential(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.Claims.Expired(time.Now()) {
+		return c, nil, fmt.Errorf("credential is expired or not yet valid")
+	}
+
+	v := &IdentityEntryVerdict{
+		Status:        IdentityEntryBadSignature,
+		SignerChainID: c.IssuerChainID,
+		SignerKey:     c.SignerKey,
+		SignerLevel:   -1,
+	}
+	if !c.verifySignature() {
+		return c, v, nil
+	}
+
+	if anchorEntryHash == "" {
+		heights, err := GetHeights()
+		if err != nil {
+			return c, nil, err
+		}
+		v.Height = heights.DirectoryBlockHeight
+	} else {
+		anchor, height, err := getCredentialAnchor(anchorEntryHash)
+		if err != nil {
+			return c, nil, err
+		}
+		av, err := validateCredentialAnchor(anchor, height, keysAt)
+		if err != nil {
+			return c, nil, err
+		}
+		if av.Status == IdentityEntryBadSignature {
+			return c, nil, fmt.Errorf("entry %s is not a valid credential anchor", anchorEntryHash)
+		}
+		if !bytes.Equal(anchor.ExtIDs[1], []byte(hex.EncodeToString(c.Hash()))) {
+			return c, nil, fmt.Errorf("anchor entry %s does not hold the credential hash", anchorEntryHash)
+		}
+		if !av.Valid() || av.SignerChainID != c.IssuerChainID {
+			return c, nil, fmt.Errorf("anchor entry %s is not signed by the issuer: %s", anchorEntryHash, av.Status)
+		}
+		v.Height = height
+	}
+
+	return c, v, checkSignerKey(v, keysAt)
+}
+
+// NewCredentialAnchorEntry creates and returns an Entry that anchors the hash
+// of a credential in a chain, signed by an Identity Key of the issuer. Only the
+// hash is published. Publish it to the blockchain using the usual
+// factom.CommitEntry(...) and factom.RevealEntry(...) calls.
+func NewCredentialAnchorEntry(destinationChainID, token string, signerKey *IdentityKey, signerChainID string) (*Entry, error) {
+	return NewCredentialAnchorEntryWithSigner(destinationChainID, token, signerKey.Signer(), signerChainID)
+}
+
+// NewCredentialAnchorEntryWithSigner creates the anchor Entry like
+// NewCredentialAnchorEntry, signed by the Identity Key held by signer.
+func NewCredentialAnchorEntryWithSigner(destinationChainID, token string, signer Signer, signerChainID string) (*Entry, error) {
+	c, err := ParseCredential(token)
+	if err != nil {
+		return nil, err
+	}
+	credentialHash := hex.EncodeToString(c.Hash())
+
+	message := []byte(destinationChainID + credentialHash)
+	signature, err := signWith(signer, message)
+	if err != nil {
+		return nil, err
+	}
+
+	e := Entry{}
+	e.ChainID = destinationChainID
+	e.ExtIDs = [][]byte{
+		[]byte("CredentialAnchor"),
+		[]byte(credentialHash),
+		signature,
+		[]byte(SignerIdentityKey(signer)),
+		[]byte(signerChainID),
+	}
+	return &e, nil
+}
+
+// IsValidCredentialAnchor returns true if the entry is a properly formatted
+// credential anchor with a verifiable signature.
+// Note: does not check that the signer key was valid for the signer identity at the time of publishing.
+func IsValidCredentialAnchor(e *Entry) bool {
+	if len(e.ExtIDs) < 5 || string(e.ExtIDs[0]) != "CredentialAnchor" {
+		return false
+	}
+	if len(e.ExtIDs[1]) != 64 || len(e.ExtIDs[2]) != ed.SignatureSize || len(e.ExtIDs[4]) != 64 {
+		return false
+	}
+	signerPubString := string(e.ExtIDs[3])
+	if IdentityKeyStringType(signerPubString) != IDPub {
+		return false
+	}
+	var signerKey [ed.PublicKeySize]byte
+	copy(signerKey[:], base58.Decode(signerPubString)[IDKeyPrefixLength:IDKeyBodyLength])
+	var signature [ed.SignatureSize]byte
+	copy(signature[:], e.ExtIDs[2])
+
+	// Message that was signed = DestinationChainID + CredentialHash
+	msg := e.ChainID + string(e.ExtIDs[1])
+	return ed.Verify(&signerKey, []byte(msg), &signature)
+}
+
+func validateCredentialAnchor(e *Entry, height int64, keysAt identityKeysFunc) (*IdentityEntryVerdict, error) {
+	v := &IdentityEntryVerdict{Status: IdentityEntryBadSignature, Height: height, SignerLevel: -1}
+	if !IsValidCredentialAnchor(e) {
+		return v, nil
+	}
+	v.SignerKey = string(e.ExtIDs[3])
+	v.SignerChainID = string(e.ExtIDs[4])
+	return v, checkSignerKey(v, keysAt)
+}
+
+// getCredentialAnchor returns the anchor entry and the height of the Entry
+// Block that included it.
+func getCredentialAnchor(entryHash string) (*Entry, int64, error) {
+	r, err := GetReceipt(entryHash)
+	if err != nil {
+		return nil, 0, err
+	}
+	if r == nil || r.EntryBlockKeyMR == "" {
+		return nil, 0, fmt.Errorf("anchor entry %s is not yet included in a block", entryHash)
+	}
+	eb, err := GetEBlock(r.EntryBlockKeyMR)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, v := range eb.EntryList {
+		if v.EntryHash != entryHash {
+			continue
+		}
+		e, err := GetEntry(entryHash)
+		if err != nil {
+			return nil, 0, err
+		}
+		return e, eb.Header.DBHeight, nil
+	}
+	return nil, 0, fmt.Errorf("anchor entry %s is not in entry block %s", entryHash, r.EntryBlockKeyMR)
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"encoding/hex"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestVerifyCredential(t *testing.T) {
+	k := testIdentityKeys(t)
+	f := newFakeFactomd()
+
+	c, _ := NewIdentityChain([]string{"Issuer"}, []string{k[0].PubString(), k[1].PubString()})
+	issuer := c.ChainID
+	f.addBlock(10, c.FirstEntry)
+	r, _ := NewIdentityKeyReplacementEntry(issuer, k[1].PubString(), k[2].PubString(), k[0])
+	f.addBlock(20, r)
+
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	SetFactomdServer(ts.URL[7:])
+
+	issue := func(key *IdentityKey, height int64) string {
+		claims := &CredentialClaims{
+			Subject:    DIDFromChainID(GetIdentityChainID([]string{"Subject"})),
+			Credential: []byte(`{"type":["VerifiableCredential"],"credentialSubject":{"kyc":"passed"}}`),
+		}
+		token, err := IssueCredential(claims, issuer, height, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	// the claimed height is not trusted: k[1] was replaced at height 20
+	token := issue(k[1], 15)
+	cred, v, err := VerifyCredential(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Status != IdentityEntrySignerKeyNotActive || v.Height != 20 || cred.IssuerChainID != issuer || cred.Claims.Height != 15 {
+		t.Errorf("expected a retired key at the current height, found %+v", v)
+	}
+
+	current := issue(k[2], 25)
+	if _, v, err := VerifyCredential(current); err != nil || !v.Valid() || v.SignerLevel != 1 {
+		t.Errorf("expected a valid credential, found %v %v", v, err)
+	}
+
+	parts := strings.Split(current, ".")
+	tampered := parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2]))
+	if _, v, err := VerifyCredential(tampered); err != nil || v.Status != IdentityEntryBadSignature {
+		t.Errorf("expected a bad signature, found %v %v", v, err)
+	}
+
+	expired := &CredentialClaims{ExpiresAt: time.Now().Add(-time.Hour).Unix()}
+	et, _ := IssueCredential(expired, issuer, 15, k[0])
+	if _, _, err := VerifyCredential(et); err == nil {
+		t.Error("expected an error for an expired credential")
+	}
+
+	// a credential anchored before the key was replaced stays valid, one
+	// backdated with a retired key is rejected
+	anchorChain := GetIdentityChainID([]string{"Anchors"})
+	a1, err := NewCredentialAnchorEntry(anchorChain, token, k[0], issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.addBlock(15, a1)
+	backdated := issue(k[1], 16)
+	a2, _ := NewCredentialAnchorEntry(anchorChain, backdated, k[0], issuer)
+	a3, _ := NewCredentialAnchorEntry(anchorChain, current, k[0], issuer)
+	f.addBlock(30, a2, a3)
+
+	if _, v, err := VerifyAnchoredCredential(token, hex.EncodeToString(a1.Hash())); err != nil || !v.Valid() || v.Height != 15 {
+		t.Errorf("expected a valid credential at the anchor height, found %v %v", v, err)
+	}
+	if _, v, err := VerifyAnchoredCredential(backdated, hex.EncodeToString(a2.Hash())); err != nil || v.Status != IdentityEntrySignerKeyNotActive || v.Height != 30 {
+		t.Errorf("expected a retired key at the anchor height, found %v %v", v, err)
+	}
+	if _, v, err := VerifyAnchoredCredential(current, hex.EncodeToString(a3.Hash())); err != nil || !v.Valid() {
+		t.Errorf("expected a valid anchored credential, found %v %v", v, err)
+	}
+	if _, _, err := VerifyAnchoredCredential(current, hex.EncodeToString(a2.Hash())); err == nil {
+		t.Error("expected an error for an anchor of another credential")
+	}
+	if _, _, err := VerifyAnchoredCredential(current, strings.Repeat("0", 64)); err == nil {
+		t.Error("expected an error for an anchor that is not in a block")
+	}
+}
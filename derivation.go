@@ -0,0 +1,176 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/FactomProject/go-bip32"
+	"github.com/FactomProject/go-bip39"
+	"github.com/FactomProject/go-bip44"
+)
+
+// DerivationPath is a BIP44 path m/44'/coin'/account'/chain/address. Hardened
+// components include bip32.FirstHardenedChild.
+type DerivationPath struct {
+	Coin    uint32
+	Account uint32
+	Chain   uint32
+	Address uint32
+}
+
+// DerivationOptions are the BIP39 passphrase and BIP44 paths used to derive the
+// addresses of a wallet from its mnemonic seed. Nil paths use the Factom
+// defaults.
+type DerivationOptions struct {
+	Passphrase   string
+	FactoidPath  *DerivationPath
+	ECPath       *DerivationPath
+	IdentityPath *DerivationPath
+}
+
+// derivationParams are the DerivationOptions as wallet request parameters.
+type derivationParams struct {
+	Passphrase   string `json:"passphrase,omitempty"`
+	FactoidPath  string `json:"factoid-path,omitempty"`
+	ECPath       string `json:"ec-path,omitempty"`
+	IdentityPath string `json:"identity-path,omitempty"`
+}
+
+func newDerivationParams(opts *DerivationOptions) derivationParams {
+	var p derivationParams
+	if opts == nil {
+		return p
+	}
+	p.Passphrase = opts.Passphrase
+	if opts.FactoidPath != nil {
+		p.FactoidPath = opts.FactoidPath.String()
+	}
+	if opts.ECPath != nil {
+		p.ECPath = opts.ECPath.String()
+	}
+	if opts.IdentityPath != nil {
+		p.IdentityPath = opts.IdentityPath.String()
+	}
+	return p
+}
+
+// NewFactoidPath returns the default path for the Factoid address at index.
+func NewFactoidPath(index uint32) *DerivationPath {
+	return &DerivationPath{bip44.TypeFactomFactoids, bip32.FirstHardenedChild, 0, index}
+}
+
+// NewECPath returns the default path for the Entry Credit address at index.
+func NewECPath(index uint32) *DerivationPath {
+	return &DerivationPath{bip44.TypeFactomEntryCredits, bip32.FirstHardenedChild, 0, index}
+}
+
+// NewIdentityPath returns the default path for the Identity Key at index.
+func NewIdentityPath(index uint32) *DerivationPath {
+	return &DerivationPath{bip44.TypeFactomIdentity, bip32.FirstHardenedChild, 0, index}
+}
+
+// ParseDerivationPath parses a path of the form m/44'/131'/0'/0/0. A trailing
+// ' or h marks a hardened component.
+func ParseDerivationPath(s string) (*DerivationPath, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) != 6 || parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q", s)
+	}
+
+	n := make([]uint32, 5)
+	for i, p := range parts[1:] {
+		hardened := strings.HasSuffix(p, "'") || strings.HasSuffix(p, "h")
+		if hardened {
+			p = p[:len(p)-1]
+		}
+		v, err := strconv.ParseUint(p, 10, 32)
+		if err != nil || v >= uint64(bip32.FirstHardenedChild) {
+			return nil, fmt.Errorf("invalid derivation path %q", s)
+		}
+		n[i] = uint32(v)
+		if hardened {
+			n[i] += bip32.FirstHardenedChild
+		}
+	}
+	if n[0] != bip32.FirstHardenedChild+44 {
+		return nil, fmt.Errorf("derivation path %q is not a BIP44 path", s)
+	}
+
+	return &DerivationPath{n[1], n[2], n[3], n[4]}, nil
+}
+
+// WithAddress returns a copy of the path with a different address index.
+func (p *DerivationPath) WithAddress(index uint32) *DerivationPath {
+	c := *p
+	c.Address = index
+	return &c
+}
+
+func (p *DerivationPath) String() string {
+	s := "m/44'"
+	for _, n := range []uint32{p.Coin, p.Account, p.Chain, p.Address} {
+		if n >= bip32.FirstHardenedChild {
+			s += fmt.Sprintf("/%d'", n-bip32.FirstHardenedChild)
+		} else {
+			s += fmt.Sprintf("/%d", n)
+		}
+	}
+	return s
+}
+
+// MakeFactoidAddressFromPath derives a Factoid Address from a mnemonic, an
+// optional BIP39 passphrase, and a derivation path.
+func MakeFactoidAddressFromPath(mnemonic, passphrase string, path *DerivationPath) (*FactoidAddress, error) {
+	key, err := deriveKey(mnemonic, passphrase, path)
+	if err != nil {
+		return nil, err
+	}
+	return MakeFactoidAddress(key)
+}
+
+// MakeECAddressFromPath derives an Entry Credit Address from a mnemonic, an
+// optional BIP39 passphrase, and a derivation path.
+func MakeECAddressFromPath(mnemonic, passphrase string, path *DerivationPath) (*ECAddress, error) {
+	key, err := deriveKey(mnemonic, passphrase, path)
+	if err != nil {
+		return nil, err
+	}
+	return MakeECAddress(key)
+}
+
+// MakeIdentityKeyFromPath derives an Identity Key from a mnemonic, an optional
+// BIP39 passphrase, and a derivation path.
+func MakeIdentityKeyFromPath(mnemonic, passphrase string, path *DerivationPath) (*IdentityKey, error) {
+	key, err := deriveKey(mnemonic, passphrase, path)
+	if err != nil {
+		return nil, err
+	}
+	return MakeIdentityKey(key)
+}
+
+func deriveKey(mnemonic, passphrase string, path *DerivationPath) ([]byte, error) {
+	mnemonic, err := ParseAndValidateMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	child, err := bip44.NewKeyFromMasterKey(masterKey, path.Coin, path.Account, path.Chain, path.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	return child.Key, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+	"github.com/FactomProject/go-bip32"
+)
+
+func TestParseDerivationPath(t *testing.T) {
+	for _, s := range []string{
+		"m/44'/131'/0'/0/0",
+		"m/44'/132'/0'/0/7",
+		"m/44'/281'/2'/1/0",
+	} {
+		p, err := ParseDerivationPath(s)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if p.String() != s {
+			t.Errorf("path mismatch: wanted %s, got %s", s, p)
+		}
+	}
+
+	if p, err := ParseDerivationPath("m/44h/131h/0h/0/0"); err != nil {
+		t.Error(err)
+	} else if p.String() != NewFactoidPath(0).String() {
+		t.Errorf("unexpected path %s", p)
+	}
+
+	for _, s := range []string{
+		"",
+		"44'/131'/0'/0/0",
+		"m/44'/131'/0'/0",
+		"m/49'/131'/0'/0/0",
+		"m/44'/abc'/0'/0/0",
+		"m/44'/131'/0'/0/4294967295",
+	} {
+		if _, err := ParseDerivationPath(s); err == nil {
+			t.Errorf("expected error for path %q", s)
+		}
+	}
+}
+
+func TestDerivationPathWithAddress(t *testing.T) {
+	p := NewECPath(0)
+	q := p.WithAddress(5)
+	if p.Address != 0 {
+		t.Error("WithAddress modified the original path")
+	}
+	if q.String() != "m/44'/132'/0'/0/5" {
+		t.Errorf("unexpected path %s", q)
+	}
+}
+
+func TestBIP44DerivationPassphrase(t *testing.T) {
+	m := "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow"
+
+	a, err := MakeBIP44FactoidAddress(m, bip32.FirstHardenedChild, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MakeFactoidAddressFromPath(m, "", NewFactoidPath(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != b.String() {
+		t.Errorf("default path mismatch: %s != %s", a, b)
+	}
+
+	c, err := MakeFactoidAddressFromPath(m, "secret", NewFactoidPath(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.String() == a.String() {
+		t.Error("passphrase did not change the derived address")
+	}
+}
+
+func TestRestoreWalletSeed(t *testing.T) {
+	params := make(map[string]interface{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req, _ := ParseJSON2Request(string(body))
+		json.Unmarshal(req.Params, &params)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"jsonrpc": "2.0", "id": 0, "result": {"success": true}}`)
+	}))
+	defer ts.Close()
+
+	SetWalletServer(ts.URL[7:])
+
+	m := "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow"
+	opts := &DerivationOptions{
+		Passphrase:  "secret",
+		FactoidPath: &DerivationPath{Coin: bip32.FirstHardenedChild + 131, Account: bip32.FirstHardenedChild + 1},
+	}
+	if err := RestoreWalletSeed(m, opts, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"mnemonic":     m,
+		"gap-limit":    float64(5),
+		"passphrase":   "secret",
+		"factoid-path": "m/44'/131'/1'/0/0",
+	}
+	if len(params) != len(expected) {
+		t.Errorf("unexpected params %v", params)
+	}
+	for k, v := range expected {
+		if params[k] != v {
+			t.Errorf("expected %s %v, found %v", k, v, params[k])
+		}
+	}
+}
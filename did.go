@@ -0,0 +1,188 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/FactomProject/btcutil/base58"
+)
+
+// DIDPrefix is the prefix of a did:factom DID. The method specific identifier
+// is the identity chain ID.
+const DIDPrefix = "did:factom:"
+
+var didContext = []string{
+	"https://www.w3.org/ns/did/v1",
+	"https://w3id.org/security/suites/ed25519-2018/v1",
+}
+
+// DIDDocument is the W3C DID document of a Factom identity.
+type DIDDocument struct {
+	Context            []string                 `json:"@context"`
+	ID                 string                   `json:"id"`
+	VerificationMethod []*DIDVerificationMethod `json:"verificationMethod"`
+	Authentication     []string                 `json:"authentication"`
+	AssertionMethod    []string                 `json:"assertionMethod"`
+	Service            []*DIDService            `json:"service,omitempty"`
+
+	// ReplacedKeys are the Identity Keys that were replaced, in the order they
+	// were replaced. They are kept out of verificationMethod so that a DID
+	// verifier does not accept signatures from retired keys.
+	ReplacedKeys []*DIDVerificationMethod `json:"factomReplacedKeys,omitempty"`
+
+	// Height is the block height the document was resolved at
+	Height int64 `json:"-"`
+}
+
+// DIDVerificationMethod is an Identity Key of a DID document.
+type DIDVerificationMethod struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	Controller      string `json:"controller"`
+	PublicKeyBase58 string `json:"publicKeyBase58"`
+	// Priority is the level of the key in the identity, 0 being the highest
+	Priority        int   `json:"priority"`
+	ActivatedHeight int64 `json:"activatedHeight,omitempty"`
+	// DeactivatedHeight is the height a replaced key was replaced at
+	DeactivatedHeight int64 `json:"deactivatedHeight,omitempty"`
+}
+
+// DIDService is a service endpoint of a DID document.
+type DIDService struct {
+	ID              string      `json:"id"`
+	Type            string      `json:"type"`
+	ServiceEndpoint interface{} `json:"serviceEndpoint"`
+}
+
+// DIDFromChainID returns the did:factom DID of the identity chain.
+func DIDFromChainID(chainID string) string {
+	return DIDPrefix + chainID
+}
+
+// ParseDID returns the identity chain ID of a did:factom DID. A fragment or
+// path after the chain ID is ignored.
+func ParseDID(did string) (string, error) {
+	if !strings.HasPrefix(did, DIDPrefix) {
+		return "", fmt.Errorf("%s is not a did:factom DID", did)
+	}
+	chainID := strings.TrimPrefix(did, DIDPrefix)
+	if i := strings.IndexAny(chainID, "#/?"); i >= 0 {
+		chainID = chainID[:i]
+	}
+	if p, err := hex.DecodeString(chainID); err != nil || len(p) != 32 {
+		return "", fmt.Errorf("invalid chain ID in DID %s", did)
+	}
+	return chainID, nil
+}
+
+// ResolveDID resolves a did:factom DID to the DID document of the identity at
+// the block height.
+func ResolveDID(did string, height int64) (*DIDDocument, error) {
+	chainID, err := ParseDID(did)
+	if err != nil {
+		return nil, err
+	}
+	i, err := GetIdentity(chainID, height)
+	if err != nil {
+		return nil, err
+	}
+	return i.DIDDocument(), nil
+}
+
+// DIDDocument returns the DID document of the resolved identity.
+//
+// The active Identity Keys are the verification methods in priority order,
+// and can be used for authentication and assertions. Replaced keys are only
+// listed in ReplacedKeys. Attributes that the identity
+// assigned to itself whose value is an object with a type and a
+// serviceEndpoint are service endpoints, with a later attribute for the same
+// key replacing an earlier one.
+func (i *Identity) DIDDocument() *DIDDocument {
+	did := DIDFromChainID(i.ChainID)
+
+	d := new(DIDDocument)
+	d.Context = didContext
+	d.ID = did
+	d.Height = i.Height
+	d.VerificationMethod = make([]*DIDVerificationMethod, 0)
+	d.Authentication = make([]string, 0)
+	d.AssertionMethod = make([]string, 0)
+
+	activated := make(map[string]int64)
+	for _, r := range i.KeyHistory {
+		activated[r.NewKey] = r.Height
+	}
+	for level, k := range i.Keys {
+		m := newDIDVerificationMethod(did, k.PubString(), level)
+		m.ActivatedHeight = activated[k.PubString()]
+		d.VerificationMethod = append(d.VerificationMethod, m)
+		d.Authentication = append(d.Authentication, m.ID)
+		d.AssertionMethod = append(d.AssertionMethod, m.ID)
+	}
+	for _, r := range i.KeyHistory {
+		m := newDIDVerificationMethod(did, r.OldKey, r.Level)
+		m.ActivatedHeight = activated[r.OldKey]
+		m.DeactivatedHeight = r.Height
+		d.ReplacedKeys = append(d.ReplacedKeys, m)
+	}
+
+	services := make(map[string]*DIDService)
+	var order []string
+	for _, a := range i.Attributes {
+		if a.SignerChainID != i.ChainID {
+			continue
+		}
+		for _, attr := range a.Attributes {
+			s := didService(did, attr)
+			if s == nil {
+				continue
+			}
+			if _, ok := services[s.ID]; !ok {
+				order = append(order, s.ID)
+			}
+			services[s.ID] = s
+		}
+	}
+	for _, id := range order {
+		d.Service = append(d.Service, services[id])
+	}
+
+	return d
+}
+
+func newDIDVerificationMethod(did, idpub string, priority int) *DIDVerificationMethod {
+	m := new(DIDVerificationMethod)
+	m.ID = did + "#" + idpub
+	m.Type = "Ed25519VerificationKey2018"
+	m.Controller = did
+	m.PublicKeyBase58 = base58.Encode(base58.Decode(idpub)[IDKeyPrefixLength:IDKeyBodyLength])
+	m.Priority = priority
+	return m
+}
+
+// didService returns the service endpoint of an attribute, or nil if the
+// attribute is not a service. The attribute key is escaped to form the
+// fragment of the service ID.
+func didService(did string, attr IdentityAttribute) *DIDService {
+	key, ok := attr.Key.(string)
+	if !ok || key == "" {
+		return nil
+	}
+	value, err := json.Marshal(attr.Value)
+	if err != nil {
+		return nil
+	}
+	s := new(DIDService)
+	if err := json.Unmarshal(value, s); err != nil || s.Type == "" || s.ServiceEndpoint == nil {
+		return nil
+	}
+	s.ID = did + "#" + url.PathEscape(key)
+	return s
+}
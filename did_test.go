@@ -0,0 +1,89 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestParseDID(t *testing.T) {
+	chainID := "e0cf1713b492e09e783d5d9f4fc6e2c71b5bdc9af4806a7937a5e935819717e9"
+	for _, did := range []string{DIDFromChainID(chainID), DIDFromChainID(chainID) + "#key"} {
+		c, err := ParseDID(did)
+		if err != nil {
+			t.Error(err)
+		} else if c != chainID {
+			t.Errorf("expected %s, found %s", chainID, c)
+		}
+	}
+	for _, did := range []string{"did:web:example.com", "did:factom:1234", chainID} {
+		if _, err := ParseDID(did); err == nil {
+			t.Errorf("expected an error for %s", did)
+		}
+	}
+}
+
+func TestResolveDID(t *testing.T) {
+	k := testIdentityKeys(t)
+	f := newFakeFactomd()
+
+	c, _ := NewIdentityChain([]string{"DID"}, []string{k[0].PubString(), k[1].PubString()})
+	chainID := c.ChainID
+	f.addBlock(10, c.FirstEntry)
+
+	r, _ := NewIdentityKeyReplacementEntry(chainID, k[1].PubString(), k[2].PubString(), k[0])
+	svc := NewIdentityAttributeEntry(chainID, chainID, `[{"key":"hub","value":{"type":"IdentityHub","serviceEndpoint":"https://hub.example.com"}},{"key":"name","value":"Alice"},{"key":"my hub#1","value":{"type":"IdentityHub","serviceEndpoint":"https://hub2.example.com"}}]`, k[0], chainID)
+	other, _ := NewIdentityChain([]string{"Other"}, []string{k[3].PubString()})
+	f.addBlock(10, other.FirstEntry)
+	spoof := NewIdentityAttributeEntry(chainID, chainID, `[{"key":"evil","value":{"type":"IdentityHub","serviceEndpoint":"https://evil.example.com"}}]`, k[3], other.ChainID)
+	f.addBlock(20, r, svc, spoof)
+
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	SetFactomdServer(ts.URL[7:])
+
+	did := DIDFromChainID(chainID)
+	d, err := ResolveDID(did, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.ID != did || len(d.VerificationMethod) != 2 || len(d.Service) != 0 {
+		t.Errorf("unexpected document at height 15: %+v", d)
+	}
+
+	d, err = ResolveDID(did, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the replaced key is not a verification method
+	if len(d.VerificationMethod) != 2 {
+		t.Fatalf("expected 2 verification methods, found %d", len(d.VerificationMethod))
+	}
+	if m := d.VerificationMethod[1]; m.ID != did+"#"+k[2].PubString() || m.Priority != 1 || m.DeactivatedHeight != 0 || m.ActivatedHeight != 20 {
+		t.Errorf("unexpected active verification method %+v", m)
+	}
+	if len(d.ReplacedKeys) != 1 {
+		t.Fatalf("expected 1 replaced key, found %d", len(d.ReplacedKeys))
+	}
+	if m := d.ReplacedKeys[0]; m.ID != did+"#"+k[1].PubString() || m.DeactivatedHeight != 20 {
+		t.Errorf("unexpected replaced key %+v", m)
+	}
+	if len(d.Authentication) != 2 || d.Authentication[0] != d.VerificationMethod[0].ID {
+		t.Errorf("expected the active keys for authentication, found %v", d.Authentication)
+	}
+	if len(d.Service) != 2 || d.Service[0].ID != did+"#hub" || d.Service[0].ServiceEndpoint != "https://hub.example.com" {
+		t.Errorf("expected the hub services only, found %+v", d.Service)
+	} else if d.Service[1].ID != did+"#my%20hub%231" {
+		t.Errorf("expected an escaped service ID, found %s", d.Service[1].ID)
+	}
+
+	if _, err := json.Marshal(d); err != nil {
+		t.Error(err)
+	}
+}
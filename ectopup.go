@@ -0,0 +1,243 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTopUpDailyLimit = errors.New("factom: EC top-up would exceed the maximum daily spend")
+)
+
+// ECPurchase is the audit record of a single Entry Credit purchase made by an
+// ECTopUp.
+type ECPurchase struct {
+	Time      time.Time `json:"time"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Balance   int64     `json:"balance"`
+	ECAmount  uint64    `json:"ecamount"`
+	Rate      uint64    `json:"rate"`
+	Fee       uint64    `json:"fee"`
+	Factoshis uint64    `json:"factoshis"`
+	TxID      string    `json:"txid,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func (p *ECPurchase) String() string {
+	var s string
+	s += fmt.Sprintln("Time:", p.Time)
+	s += fmt.Sprintln("From:", p.From)
+	s += fmt.Sprintln("To:", p.To)
+	s += fmt.Sprintln("Balance:", p.Balance)
+	s += fmt.Sprintln("ECAmount:", p.ECAmount)
+	s += fmt.Sprintln("Rate:", p.Rate)
+	s += fmt.Sprintln("Fee:", FactoshiToFactoid(p.Fee))
+	s += fmt.Sprintln("Cost:", FactoshiToFactoid(p.Factoshis))
+	if p.TxID != "" {
+		s += fmt.Sprintln("TxID:", p.TxID)
+	}
+	if p.Error != "" {
+		s += fmt.Sprintln("Error:", p.Error)
+	}
+	return s
+}
+
+// ECTopUp keeps the balance of an Entry Credit address above a threshold by
+// buying Entry Credits from a Factoid address held in the wallet.
+type ECTopUp struct {
+	// FactoidAddress is the public Factoid address (FA...) that pays for the
+	// Entry Credits. Its secret must be in the wallet.
+	FactoidAddress string
+	// ECAddress is the public Entry Credit address (EC...) to refill.
+	ECAddress string
+	// Threshold is the Entry Credit balance below which a purchase is made.
+	Threshold int64
+	// Amount is the number of Entry Credits bought with each purchase, or the
+	// minimum when Ensure needs more.
+	Amount uint64
+	// MaxDailySpend is the maximum number of factoshis spent on purchases,
+	// including transaction fees, in any 24 hour period. A value of 0 means no
+	// limit.
+	MaxDailySpend uint64
+	// Force skips the wallet balance and fee checks when signing.
+	Force bool
+	// OnPurchase, if set, is called with the audit record of every purchase
+	// attempt, successful or not.
+	OnPurchase func(*ECPurchase)
+
+	mtx       sync.Mutex
+	purchases []*ECPurchase
+}
+
+// ecTopUpRecords is the number of audit records kept by an ECTopUp. Older
+// records are dropped, except for purchases still counted against
+// MaxDailySpend.
+const ecTopUpRecords = 1000
+
+// NewECTopUp creates an ECTopUp that buys amount Entry Credits for ec from fct
+// whenever the balance of ec falls below threshold.
+func NewECTopUp(fct, ec string, threshold int64, amount uint64) (*ECTopUp, error) {
+	if AddressStringType(fct) != FactoidPub {
+		return nil, fmt.Errorf("%s is not a Factoid address", fct)
+	}
+	if AddressStringType(ec) != ECPub {
+		return nil, fmt.Errorf("%s is not an Entry Credit address", ec)
+	}
+	if amount == 0 {
+		return nil, fmt.Errorf("top-up amount must be greater than 0")
+	}
+
+	t := new(ECTopUp)
+	t.FactoidAddress = fct
+	t.ECAddress = ec
+	t.Threshold = threshold
+	t.Amount = amount
+	return t, nil
+}
+
+// Check tops up the Entry Credit address if its balance is below the
+// threshold. It returns the audit record of the purchase, or nil if no purchase
+// was needed.
+func (t *ECTopUp) Check() (*ECPurchase, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	balance, err := GetECBalance(t.ECAddress)
+	if err != nil {
+		return nil, err
+	}
+	if balance >= t.Threshold {
+		return nil, nil
+	}
+
+	return t.buy(balance, t.Amount)
+}
+
+// Ensure tops up the Entry Credit address if its balance is below the threshold
+// or too low to pay for needed Entry Credits. It buys Amount Entry Credits, or
+// as many as are missing if that is more.
+func (t *ECTopUp) Ensure(needed int64) (*ECPurchase, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	balance, err := GetECBalance(t.ECAddress)
+	if err != nil {
+		return nil, err
+	}
+	if balance >= t.Threshold && balance >= needed {
+		return nil, nil
+	}
+
+	amount := t.Amount
+	if needed-balance > int64(amount) {
+		amount = uint64(needed - balance)
+	}
+	return t.buy(balance, amount)
+}
+
+// Purchases returns the audit records of the most recent purchase attempts
+// made by the ECTopUp, oldest first. At most 1000 records are kept, plus any
+// successful purchases made in the last 24 hours.
+func (t *ECTopUp) Purchases() []*ECPurchase {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	p := make([]*ECPurchase, len(t.purchases))
+	copy(p, t.purchases)
+	return p
+}
+
+// SpentSince returns the number of factoshis spent on successful purchases
+// since the given time.
+func (t *ECTopUp) SpentSince(since time.Time) uint64 {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.spentSince(since)
+}
+
+func (t *ECTopUp) spentSince(since time.Time) uint64 {
+	var total uint64
+	for _, p := range t.purchases {
+		if p.Error == "" && p.Time.After(since) {
+			total += p.Factoshis
+		}
+	}
+	return total
+}
+
+// buy purchases amount Entry Credits at the current rate. The cost, including
+// the transaction fee, is checked against MaxDailySpend before the transaction
+// is signed. The caller must hold t.mtx.
+func (t *ECTopUp) buy(balance int64, amount uint64) (*ECPurchase, error) {
+	rate, err := GetRate()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	p := &ECPurchase{
+		Time:     now,
+		From:     t.FactoidAddress,
+		To:       t.ECAddress,
+		Balance:  balance,
+		ECAmount: amount,
+		Rate:     rate,
+	}
+
+	name, tx, err := newECPurchaseTransaction(t.FactoidAddress, t.ECAddress, amount, rate)
+	if err != nil {
+		p.Error = err.Error()
+		t.record(p)
+		return p, err
+	}
+	p.Fee = tx.FeesPaid
+	p.Factoshis = amount*rate + tx.FeesPaid
+
+	if t.MaxDailySpend != 0 {
+		spent := t.spentSince(now.Add(-24 * time.Hour))
+		if spent+p.Factoshis > t.MaxDailySpend {
+			err := discardTransaction(name, ErrTopUpDailyLimit)
+			p.Error = err.Error()
+			t.record(p)
+			return p, err
+		}
+	}
+
+	tx, err = signAndSendTransaction(name, t.Force)
+	if err != nil {
+		p.Error = err.Error()
+		t.record(p)
+		return p, err
+	}
+	p.TxID = tx.TxID
+	t.record(p)
+
+	return p, nil
+}
+
+func (t *ECTopUp) record(p *ECPurchase) {
+	t.purchases = append(t.purchases, p)
+	if extra := len(t.purchases) - ecTopUpRecords; extra > 0 {
+		day := p.Time.Add(-24 * time.Hour)
+		kept := make([]*ECPurchase, 0, ecTopUpRecords)
+		for _, r := range t.purchases {
+			if extra > 0 && (r.Error != "" || !r.Time.After(day)) {
+				extra--
+				continue
+			}
+			kept = append(kept, r)
+		}
+		t.purchases = kept
+	}
+	if t.OnPurchase != nil {
+		t.OnPurchase(p)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestECTopUp(t *testing.T) {
+	var (
+		fa = "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"
+		ec = "EC3MAHiZyfuEb5fZP2fSp2gXMv8WemhQEUFXyQ2f2HjSkYx7xY1S"
+	)
+
+	balance := 5
+	txname := ""
+	submitted := 0
+
+	// simulate both factomd and the wallet on the same server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req, _ := ParseJSON2Request(string(body))
+		w.Header().Set("Content-Type", "application/json")
+
+		var result string
+		switch req.Method {
+		case "entry-credit-balance":
+			result = fmt.Sprintf(`{"balance": %d}`, balance)
+		case "entry-credit-rate":
+			result = `{"rate": 1000}`
+		case "new-transaction":
+			p := new(struct {
+				Name string `json:"tx-name"`
+			})
+			json.Unmarshal(req.Params, p)
+			txname = p.Name
+			result = `{"signed": false}`
+		case "add-fee":
+			result = `{"signed": false, "feespaid": 12000}`
+		case "add-input", "add-ec-output", "sign-transaction", "delete-transaction":
+			result = `{"signed": true}`
+		case "tmp-transactions":
+			result = fmt.Sprintf(`{"transactions": [{"name": %q, "signed": true, "txid": "abcd"}]}`, txname)
+		case "compose-transaction":
+			result = `{"jsonrpc": "2.0", "id": 1, "method": "factoid-submit", "params": {"transaction": "00"}}`
+		case "factoid-submit":
+			submitted++
+			result = `{"message": "Successfully submitted the transaction", "txid": "abcd"}`
+		default:
+			t.Errorf("unexpected api call %s", req.Method)
+			result = `{}`
+		}
+		fmt.Fprintf(w, `{"jsonrpc": "2.0", "id": 0, "result": %s}`, result)
+	}))
+	defer ts.Close()
+
+	SetFactomdServer(ts.URL[7:])
+	SetWalletServer(ts.URL[7:])
+
+	topup, err := NewECTopUp(fa, ec, 10, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	topup.MaxDailySpend = 220000
+
+	audited := 0
+	topup.OnPurchase = func(p *ECPurchase) { audited++ }
+
+	// balance is below the threshold; buy 100 EC at 1000 factoshis each, plus
+	// the fee
+	p, err := topup.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil {
+		t.Fatal("expected a purchase")
+	}
+	if p.Factoshis != 112000 || p.Fee != 12000 || p.Rate != 1000 || p.TxID != "abcd" || submitted != 1 {
+		t.Errorf("unexpected purchase %v", p)
+	}
+
+	// the next purchase would go over the daily limit once its fee is counted
+	if _, err := topup.Check(); err != ErrTopUpDailyLimit {
+		t.Errorf("expected daily limit error, got %v", err)
+	}
+	if submitted != 1 {
+		t.Errorf("transaction submitted past the daily limit")
+	}
+
+	// balance is above the threshold; nothing to do
+	balance = 50
+	if p, err := topup.Check(); err != nil {
+		t.Error(err)
+	} else if p != nil {
+		t.Errorf("unexpected purchase %v", p)
+	}
+
+	if audited != 2 || len(topup.Purchases()) != 2 {
+		t.Errorf("expected 2 audit records, got %d", len(topup.Purchases()))
+	}
+	if spent := topup.SpentSince(p.Time.Add(-1)); spent != 112000 {
+		t.Errorf("expected 112000 factoshis spent, got %d", spent)
+	}
+
+	// more Entry Credits are needed than a single top-up amount
+	topup.MaxDailySpend = 0
+	p, err = topup.Ensure(250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || p.ECAmount != 200 || p.Factoshis != 212000 {
+		t.Errorf("unexpected purchase %v", p)
+	}
+
+	if _, err := NewECTopUp(ec, fa, 10, 100); err == nil {
+		t.Error("expected error for swapped addresses")
+	}
+}
+
+func TestECTopUpCleanup(t *testing.T) {
+	var (
+		fa = "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"
+		ec = "EC3MAHiZyfuEb5fZP2fSp2gXMv8WemhQEUFXyQ2f2HjSkYx7xY1S"
+	)
+
+	txname := ""
+	deleted := ""
+	deleteFails := false
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req, _ := ParseJSON2Request(string(body))
+		w.Header().Set("Content-Type", "application/json")
+
+		p := new(struct {
+			Name string `json:"tx-name"`
+		})
+		json.Unmarshal(req.Params, p)
+
+		var result string
+		switch req.Method {
+		case "entry-credit-balance":
+			result = `{"balance": 5}`
+		case "entry-credit-rate":
+			result = `{"rate": 1000}`
+		case "new-transaction":
+			txname = p.Name
+			result = `{"signed": false}`
+		case "add-input", "add-ec-output":
+			result = `{"signed": false}`
+		case "add-fee":
+			result = `{"signed": false, "feespaid": 12000}`
+		case "sign-transaction":
+			fmt.Fprint(w, `{"jsonrpc": "2.0", "id": 0, "error": {"code": -32603, "message": "Internal error", "data": "insufficient balance"}}`)
+			return
+		case "delete-transaction":
+			if deleteFails {
+				fmt.Fprint(w, `{"jsonrpc": "2.0", "id": 0, "error": {"code": -32603, "message": "Internal error", "data": "wallet locked"}}`)
+				return
+			}
+			deleted = p.Name
+			result = `{"signed": false}`
+		default:
+			t.Errorf("unexpected api call %s", req.Method)
+			result = `{}`
+		}
+		fmt.Fprintf(w, `{"jsonrpc": "2.0", "id": 0, "result": %s}`, result)
+	}))
+	defer ts.Close()
+
+	SetFactomdServer(ts.URL[7:])
+	SetWalletServer(ts.URL[7:])
+
+	topup, err := NewECTopUp(fa, ec, 10, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a transaction that fails to sign is removed from the wallet
+	if _, err := topup.Check(); err == nil {
+		t.Error("expected a signing error")
+	}
+	if deleted == "" || deleted != txname {
+		t.Errorf("transaction %q was not deleted", txname)
+	}
+
+	// an error deleting the transaction is returned with the original error
+	deleteFails = true
+	if _, err := topup.Check(); err == nil || !strings.Contains(err.Error(), "wallet locked") {
+		t.Errorf("expected the delete error, got %v", err)
+	}
+
+	// the audit history is capped
+	deleteFails = false
+	for i := 0; i < 1005; i++ {
+		topup.Check()
+	}
+	if n := len(topup.Purchases()); n != 1000 {
+		t.Errorf("expected 1000 audit records, got %d", n)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"fmt"
+	"sort"
+)
+
+// EndorsementGraph is a directed graph of identities and the attributes
+// assigned to them, built from the IdentityAttribute and
+// IdentityAttributeEndorsement entries of a set of chains. Only entries that
+// are valid when checked against the signer identity at their block height are
+// added.
+//
+// An identity trusts another identity if it issued or endorsed an attribute
+// assigned to it. The issuer of an attribute counts as its first endorser.
+type EndorsementGraph struct {
+	// Cache resolves the keys of signer identities
+	Cache *IdentityKeyCache
+
+	attributes   map[string]*IdentityAttributeEntry
+	endorsements map[string][]*IdentityEndorsement
+	chains       map[string]bool
+}
+
+// TrustPath is a chain of trust from a root identity to an endorser of an
+// attribute. Path starts with the root and ends with the identity that endorsed
+// or issued the attribute, and each identity in it trusts the next.
+type TrustPath struct {
+	Root               string   `json:"root"`
+	AttributeEntryHash string   `json:"attributeentryhash"`
+	Path               []string `json:"path"`
+}
+
+// Hops returns the number of trust relationships in the path, including the
+// endorsement of the attribute.
+func (p *TrustPath) Hops() int {
+	return len(p.Path)
+}
+
+// NewEndorsementGraph returns an empty EndorsementGraph using cache to resolve
+// signer identities, or a new in memory IdentityKeyCache if cache is nil.
+func NewEndorsementGraph(cache *IdentityKeyCache) *EndorsementGraph {
+	if cache == nil {
+		cache = NewIdentityKeyCache(nil)
+	}
+	g := new(EndorsementGraph)
+	g.Cache = cache
+	g.attributes = make(map[string]*IdentityAttributeEntry)
+	g.endorsements = make(map[string][]*IdentityEndorsement)
+	g.chains = make(map[string]bool)
+	return g
+}
+
+// BuildEndorsementGraph builds an EndorsementGraph from the entries of the
+// chains up to the block height.
+func BuildEndorsementGraph(chainIDs []string, height int64) (*EndorsementGraph, error) {
+	g := NewEndorsementGraph(nil)
+	for _, chainID := range chainIDs {
+		if err := g.AddChain(chainID, height); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// AddChain adds the valid attributes and endorsements in the chain up to the
+// block height to the graph. A chain is only added once.
+func (g *EndorsementGraph) AddChain(chainID string, height int64) error {
+	if g.chains[chainID] {
+		return nil
+	}
+
+	entries, err := getChainEntriesWithHeights(chainID, height)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if len(e.ExtIDs) == 0 {
+			continue
+		}
+		switch string(e.ExtIDs[0]) {
+		case "IdentityAttribute":
+			v, err := g.Cache.ValidateAttribute(e.Entry, e.Height)
+			if err != nil {
+				return err
+			}
+			if !v.Valid() {
+				continue
+			}
+			a, err := newIdentityAttributeEntry(e)
+			if err != nil {
+				continue
+			}
+			a.Endorsements = append(a.Endorsements, g.endorsements[a.EntryHash]...)
+			g.attributes[a.EntryHash] = a
+		case "IdentityAttributeEndorsement":
+			v, err := g.Cache.ValidateEndorsement(e.Entry, e.Height)
+			if err != nil {
+				return err
+			}
+			if !v.Valid() {
+				continue
+			}
+			d := newIdentityEndorsement(e)
+			g.endorsements[d.AttributeEntryHash] = append(g.endorsements[d.AttributeEntryHash], d)
+			if a, ok := g.attributes[d.AttributeEntryHash]; ok {
+				a.Endorsements = append(a.Endorsements, d)
+			}
+		}
+	}
+
+	g.chains[chainID] = true
+	return nil
+}
+
+// Attribute returns the attribute entry with the hash, or nil if it is not in
+// the graph.
+func (g *EndorsementGraph) Attribute(entryHash string) *IdentityAttributeEntry {
+	return g.attributes[entryHash]
+}
+
+// AttributesOf returns the attribute entries assigned to the identity that
+// contain an attribute with the key. All attribute entries of the identity are
+// returned if key is empty.
+func (g *EndorsementGraph) AttributesOf(chainID, key string) []*IdentityAttributeEntry {
+	as := make([]*IdentityAttributeEntry, 0)
+	for _, a := range g.attributes {
+		if a.ReceiverChainID != chainID {
+			continue
+		}
+		if key == "" {
+			as = append(as, a)
+			continue
+		}
+		for _, attr := range a.Attributes {
+			if fmt.Sprint(attr.Key) == key {
+				as = append(as, a)
+				break
+			}
+		}
+	}
+	return as
+}
+
+// endorsers returns the identities that issued or endorsed the attribute.
+func (g *EndorsementGraph) endorsers(a *IdentityAttributeEntry) map[string]bool {
+	ids := map[string]bool{a.SignerChainID: true}
+	for _, d := range a.Endorsements {
+		ids[d.SignerChainID] = true
+	}
+	return ids
+}
+
+// trusted returns the identities that the identity issued or endorsed an
+// attribute for.
+func (g *EndorsementGraph) trusted() map[string][]string {
+	edges := make(map[string]map[string]bool)
+	for _, a := range g.attributes {
+		for id := range g.endorsers(a) {
+			if id == a.ReceiverChainID {
+				continue
+			}
+			if edges[id] == nil {
+				edges[id] = make(map[string]bool)
+			}
+			edges[id][a.ReceiverChainID] = true
+		}
+	}
+
+	trusted := make(map[string][]string)
+	for id, ids := range edges {
+		for to := range ids {
+			trusted[id] = append(trusted[id], to)
+		}
+		sort.Strings(trusted[id])
+	}
+	return trusted
+}
+
+// TrustedEndorsers returns the shortest TrustPath from each of the roots to an
+// endorser of the attribute entry, for the roots that reach one within hops.
+// A root that endorsed or issued the attribute itself is 1 hop away.
+func (g *EndorsementGraph) TrustedEndorsers(attributeEntryHash string, roots []string, hops int) []*TrustPath {
+	paths := make([]*TrustPath, 0)
+	a, ok := g.attributes[attributeEntryHash]
+	if !ok || hops < 1 {
+		return paths
+	}
+
+	endorsers := g.endorsers(a)
+	trusted := g.trusted()
+	for _, root := range roots {
+		// breadth first search from the root, one hop per level
+		prev := map[string]string{root: ""}
+		level := []string{root}
+		for n := 1; n <= hops && len(level) > 0; n++ {
+			var found string
+			for _, id := range level {
+				if endorsers[id] {
+					found = id
+					break
+				}
+			}
+			if found != "" {
+				p := &TrustPath{Root: root, AttributeEntryHash: attributeEntryHash}
+				for id := found; id != ""; id = prev[id] {
+					p.Path = append([]string{id}, p.Path...)
+				}
+				paths = append(paths, p)
+				break
+			}
+
+			var next []string
+			for _, id := range level {
+				for _, to := range trusted[id] {
+					if _, seen := prev[to]; !seen {
+						prev[to] = id
+						next = append(next, to)
+					}
+				}
+			}
+			level = next
+		}
+	}
+	return paths
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestEndorsementGraph(t *testing.T) {
+	k := testIdentityKeys(t)
+	f := newFakeFactomd()
+
+	var ids []string
+	for i, name := range []string{"root", "intermediate", "issuer", "subject"} {
+		c, _ := NewIdentityChain([]string{name}, []string{k[i].PubString()})
+		f.addBlock(10, c.FirstEntry)
+		ids = append(ids, c.ChainID)
+	}
+	root, inter, issuer, subject := ids[0], ids[1], ids[2], ids[3]
+
+	// the issuer assigns kyc to the subject and the intermediate endorses it
+	kyc := NewIdentityAttributeEntry(subject, subject, `[{"key":"kyc","value":"passed"}]`, k[2], issuer)
+	kycHash := hex.EncodeToString(kyc.Hash())
+	end := NewIdentityAttributeEndorsementEntry(subject, kycHash, k[1], inter)
+	// an endorsement by a key that is not active for the root is ignored
+	bad := NewIdentityAttributeEndorsementEntry(subject, kycHash, k[4], root)
+	f.addBlock(20, kyc, end, bad)
+
+	// the root vouches for the intermediate
+	vouch := NewIdentityAttributeEntry(inter, inter, `[{"key":"member","value":true}]`, k[0], root)
+	f.addBlock(20, vouch)
+
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	SetFactomdServer(ts.URL[7:])
+
+	g, err := BuildEndorsementGraph([]string{subject, inter}, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	as := g.AttributesOf(subject, "kyc")
+	if len(as) != 1 || as[0].EntryHash != kycHash {
+		t.Fatalf("expected the kyc attribute of the subject, found %v", as)
+	}
+	if len(as[0].Endorsements) != 1 || as[0].Endorsements[0].SignerChainID != inter {
+		t.Errorf("expected 1 valid endorsement by the intermediate")
+	}
+
+	roots := []string{root, issuer, GetIdentityChainID([]string{"stranger"})}
+	paths := g.TrustedEndorsers(kycHash, roots, 1)
+	if len(paths) != 1 || paths[0].Root != issuer || paths[0].Hops() != 1 {
+		t.Errorf("expected only the issuer within 1 hop, found %v", paths)
+	}
+
+	paths = g.TrustedEndorsers(kycHash, roots, 2)
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 trusted endorsers within 2 hops, found %d", len(paths))
+	}
+	if p := paths[0]; p.Root != root || p.Hops() != 2 || p.Path[1] != inter {
+		t.Errorf("unexpected trust path %+v", p)
+	}
+
+	if paths := g.TrustedEndorsers("00", roots, 5); len(paths) != 0 {
+		t.Errorf("expected no paths for an unknown attribute")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 type Entry struct {
@@ -155,28 +156,79 @@ func (e *Entry) UnmarshalJSON(data []byte) error {
 // factomd web api. The request includes the marshaled MessageRequest with the
 // Entry Credit Signature.
 func ComposeEntryCommit(e *Entry, ec *ECAddress) (*JSON2Request, error) {
+	return ComposeEntryCommitAt(e, ec, time.Now())
+}
+
+// ComposeEntryCommitAt creates a JSON2Request to commit a new Entry with the
+// commit timestamp ts. The same Entry, Entry Credit address, and timestamp
+// always produce the same commit. ts is not checked against the clock:
+// factomd rejects commits submitted more than CommitWindow away from ts, so
+// the caller must check ts with ValidateCommitTime before submitting the
+// commit.
+func ComposeEntryCommitAt(e *Entry, ec *ECAddress, ts time.Time) (*JSON2Request, error) {
+	p, err := e.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	// the payload size excludes the 35 byte Entry header
+	return ComposeEntryCommitByHash(e.Hash(), len(p)-35, ec, ts)
+}
+
+// ComposeEntryCommitWithSigner creates a JSON2Request to commit a new Entry
+// with the commit timestamp ts, paid for by the Entry Credit key of s. As with
+// ComposeEntryCommitAt, the caller must check ts with ValidateCommitTime.
+func ComposeEntryCommitWithSigner(e *Entry, s Signer, ts time.Time) (*JSON2Request, error) {
+	p, err := e.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return composeEntryCommit(e.Hash(), len(p)-35, s, ts)
+}
+
+// ComposeEntryCommitByHash creates a JSON2Request to commit an Entry knowing
+// only its Entry Hash and the size of its payload (ExtIDs and Content,
+// excluding the 35 byte header). The Entry itself may be revealed later by a
+// different party.
+func ComposeEntryCommitByHash(hash []byte, size int, ec *ECAddress, ts time.Time) (*JSON2Request, error) {
+	return composeEntryCommit(hash, size, ec.Signer(), ts)
+}
+
+func composeEntryCommit(hash []byte, size int, s Signer, ts time.Time) (*JSON2Request, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("Entry Hash must be 32 bytes")
+	}
+
 	buf := new(bytes.Buffer)
 
 	// 1 byte version
 	buf.Write([]byte{0})
 
 	// 6 byte milliTimestamp (truncated unix time)
-	buf.Write(milliTime())
+	if t, err := milliTimestamp(ts); err != nil {
+		return nil, err
+	} else {
+		buf.Write(t)
+	}
 
 	// 32 byte Entry Hash
-	buf.Write(e.Hash())
+	buf.Write(hash)
 
 	// 1 byte number of entry credits to pay
-	if c, err := EntryCost(e); err != nil {
+	if c, err := EntryCostFromSize(size); err != nil {
 		return nil, err
 	} else {
 		buf.WriteByte(byte(c))
 	}
 
 	// 32 byte Entry Credit Address Public Key + 64 byte Signature
-	sig := ec.Sign(buf.Bytes())
-	buf.Write(ec.PubBytes())
-	buf.Write(sig[:])
+	sig, err := signWith(s, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(s.PubBytes())
+	buf.Write(sig)
 
 	params := messageRequest{Message: hex.EncodeToString(buf.Bytes())}
 	req := NewJSON2Request("commit-entry", APICounter(), params)
@@ -202,12 +254,18 @@ func ComposeEntryReveal(e *Entry) (*JSON2Request, error) {
 // the factom network. Once the payment is verified and the network is commited
 // to publishing the Entry it may be published with a call to RevealEntry.
 func CommitEntry(e *Entry, ec *ECAddress) (string, error) {
+	return CommitEntryWithSigner(e, ec.Signer())
+}
+
+// CommitEntryWithSigner commits the Entry like CommitEntry, with the Entry
+// Credit key held by s.
+func CommitEntryWithSigner(e *Entry, s Signer) (string, error) {
 	type commitResponse struct {
 		Message string `json:"message"`
 		TxID    string `json:"txid"`
 	}
 
-	req, err := ComposeEntryCommit(e, ec)
+	req, err := ComposeEntryCommitWithSigner(e, s, time.Now())
 	if err != nil {
 		return "", err
 	}
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	. "github.com/FactomProject/factom"
 )
@@ -135,6 +136,67 @@ func TestComposeEntryCommit(t *testing.T) {
 	}
 }
 
+func TestComposeEntryCommitAt(t *testing.T) {
+	type response struct {
+		Message string `json:"message"`
+	}
+	ecAddr, _ := GetECAddress("Es2Rf7iM6PdsqfYCo3D1tnAR65SkLENyWJG1deUzpRMQmbh9F3eG")
+	ent := new(Entry)
+	ent.ChainID = "954d5a49fd70d9b8bcdb35d252267829957f7ef7fa6c74f88419bdc5e82209f4"
+	ent.Content = []byte("test!")
+	ent.ExtIDs = append(ent.ExtIDs, []byte("test"))
+
+	ts := time.Unix(1500000000, 123e6)
+	eCommit, err := ComposeEntryCommitAt(ent, ecAddr, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := new(response)
+	json.Unmarshal(eCommit.Params, r)
+
+	expected := "00015d3ef7987b285ed45081d5b8819a678d13c7c2d04f704b34c74e8aaecd9bd34609bee04720013b6a27bcceb6a42d62a3a8d02a6f0d73653215771de243a63ac048a18b59da2986cec7a3f125943266552b8626d130c8057c8c4735a280e21ef324c616dbbe745fdedb51307509b51d240479a9a757a867a92ecbc74aa70f3f46333bf0dc7705"
+	if r.Message != expected {
+		fmt.Printf("found %s expected %s\n", r.Message, expected)
+		t.Fail()
+	}
+
+	// the timestamp must fit in 6 bytes of unix milliseconds
+	if _, err := ComposeEntryCommitAt(ent, ecAddr, time.Unix(-1, 0)); err == nil {
+		t.Error("expected an error for a negative timestamp")
+	}
+}
+
+func TestComposeEntryCommitByHash(t *testing.T) {
+	ecAddr, _ := GetECAddress("Es2Rf7iM6PdsqfYCo3D1tnAR65SkLENyWJG1deUzpRMQmbh9F3eG")
+	ent := new(Entry)
+	ent.ChainID = "954d5a49fd70d9b8bcdb35d252267829957f7ef7fa6c74f88419bdc5e82209f4"
+	ent.Content = []byte("test!")
+	ent.ExtIDs = append(ent.ExtIDs, []byte("test"))
+
+	ts := time.Unix(1500000000, 123e6)
+	expected, err := ComposeEntryCommitAt(ent, ecAddr, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2 byte extid length + 4 byte extid + 5 byte content
+	result, err := ComposeEntryCommitByHash(ent.Hash(), 11, ecAddr, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result.Params, expected.Params) {
+		fmt.Printf("found %s expected %s\n", result.Params, expected.Params)
+		t.Fail()
+	}
+
+	if _, err := ComposeEntryCommitByHash(ent.Hash()[:31], 11, ecAddr, ts); err == nil {
+		t.Error("expected an error for a short entry hash")
+	}
+	if _, err := ComposeEntryCommitByHash(ent.Hash(), 10241, ecAddr, ts); err == nil {
+		t.Error("expected an error for an oversized entry")
+	}
+}
+
 func TestComposeEntryReveal(t *testing.T) {
 
 	ent := new(Entry)
@@ -388,44 +388,11 @@ func GetFirstEntry(chainid string) (*Entry, error) {
 	return GetEntry(eb.EntryList[0].EntryHash)
 }
 
+// GetProperties returns the versions of factomd and factom-walletd. Use
+// GetAllProperties to get them as a Properties struct.
 func GetProperties() (string, string, string, string, string, string, string, string) {
-	type propertiesResponse struct {
-		FactomdVersion       string `json:"factomdversion"`
-		FactomdVersionErr    string `json:"factomdversionerr"`
-		FactomdAPIVersion    string `json:"factomdapiversion"`
-		FactomdAPIVersionErr string `json:"factomdapiversionerr"`
-		WalletVersion        string `json:"walletversion"`
-		WalletVersionErr     string `json:"walletversionerr"`
-		WalletAPIVersion     string `json:"walletapiversion"`
-		WalletAPIVersionErr  string `json:"walletapiversionerr"`
-	}
-
-	props := new(propertiesResponse)
-	wprops := new(propertiesResponse)
-	req := NewJSON2Request("properties", APICounter(), nil)
-	wreq := NewJSON2Request("properties", APICounter(), nil)
-
-	resp, err := factomdRequest(req)
-	if err != nil {
-		props.FactomdVersionErr = err.Error()
-	} else if resp.Error != nil {
-		props.FactomdVersionErr = resp.Error.Error()
-	} else if jerr := json.Unmarshal(resp.JSONResult(), props); jerr != nil {
-		props.FactomdVersionErr = jerr.Error()
-	}
-
-	wresp, werr := walletRequest(wreq)
-
-	if werr != nil {
-		wprops.WalletVersionErr = werr.Error()
-	} else if wresp.Error != nil {
-		wprops.WalletVersionErr = wresp.Error.Error()
-	} else if jwerr := json.Unmarshal(wresp.JSONResult(), wprops); jwerr != nil {
-		wprops.WalletVersionErr = jwerr.Error()
-	}
-
-	return props.FactomdVersion, props.FactomdVersionErr, props.FactomdAPIVersion, props.FactomdAPIVersionErr, wprops.WalletVersion, wprops.WalletVersionErr, wprops.WalletAPIVersion, wprops.WalletAPIVersionErr
-
+	props := GetAllProperties()
+	return props.FactomdVersion, props.FactomdVersionErr, props.FactomdAPIVersion, props.FactomdAPIVersionErr, props.WalletVersion, props.WalletVersionErr, props.WalletAPIVersion, props.WalletAPIVersionErr
 }
 
 func GetPendingEntries() (string, error) {
@@ -437,7 +404,7 @@ func GetPendingEntries() (string, error) {
 		return "", err
 	}
 	if resp.Error != nil {
-		return "", err
+		return "", resp.Error
 	}
 
 	rBytes := resp.JSONResult()
@@ -454,7 +421,7 @@ func GetPendingTransactions() (string, error) {
 		return "", err
 	}
 	if resp.Error != nil {
-		return "", err
+		return "", resp.Error
 	}
 	//fmt.Println("factom resp=", resp)
 	transList := resp.JSONResult()
@@ -14,9 +14,13 @@ import (
 // An Identity is an array of names and a hierarchy of keys. It can assign/receive
 // Attributes as JSON objects and rotate/replace its currently valid keys.
 type Identity struct {
-	ChainID string
-	Name    []string
-	Keys    []*IdentityKey
+	ChainID      string
+	Name         []string
+	Keys         []*IdentityKey
+	KeyHistory   []*IdentityKeyReplacement
+	Attributes   []*IdentityAttributeEntry
+	Endorsements []*IdentityEndorsement
+	Height       int64
 }
 
 type IdentityAttribute struct {
@@ -74,108 +78,34 @@ func GetActiveIdentityKeysAtHeight(chainID string, height int64) ([]string, erro
 		return nil, fmt.Errorf("chain does not exist")
 	}
 
-	entries, err := GetAllChainEntriesAtHeight(chainID, height)
+	entries, err := getChainEntriesWithHeights(chainID, height)
 	if err != nil {
 		return nil, err
 	} else if len(entries) == 0 {
 		return nil, fmt.Errorf("chain did not yet exist at height %d", height)
-	} else if len(entries[0].ExtIDs) == 0 || bytes.Compare(entries[0].ExtIDs[0], []byte("IdentityChain")) != 0 {
-		return nil, fmt.Errorf("no identity found at chain ID: %s", chainID)
 	}
 
-	var identityInfo struct {
-		Version     int      `json:"version"`
-		InitialKeys []string `json:"keys"`
-	}
-	initialKeysJSON := entries[0].Content
-	err = json.Unmarshal(initialKeysJSON, &identityInfo)
+	s, err := newIdentityState(chainID, entries[0].Entry)
 	if err != nil {
-		return nil, fmt.Errorf("no identity found at chain ID: %s", chainID)
-	}
-
-	var activeKeys []*IdentityKey
-	allKeys := make(map[string]bool)
-	for _, pubString := range identityInfo.InitialKeys {
-		if IdentityKeyStringType(pubString) != IDPub {
-			return nil, fmt.Errorf("invalid identity public key string in first entry: %s", pubString)
-		} else if _, present := allKeys[pubString]; present {
-			continue
-		}
-		pub := base58.Decode(pubString)
-		k := NewIdentityKey()
-		copy(k.Pub[:], pub[IDKeyPrefixLength:IDKeyBodyLength])
-		activeKeys = append(activeKeys, k)
-		allKeys[pubString] = true
+		return nil, err
 	}
-
 	for _, e := range entries {
-		if len(e.ExtIDs) < 5 || bytes.Compare(e.ExtIDs[0], []byte("ReplaceKey")) != 0 {
-			continue
-		}
-		if len(e.ExtIDs[1]) != 55 || len(e.ExtIDs[2]) != 55 || len(e.ExtIDs[3]) != ed.SignatureSize {
-			continue
-		}
-
-		var oldKey [ed.PublicKeySize]byte
-		oldPubString := string(e.ExtIDs[1])
-		if IdentityKeyStringType(oldPubString) != IDPub {
-			continue
-		}
-		b := base58.Decode(oldPubString)
-		copy(oldKey[:], b[IDKeyPrefixLength:IDKeyBodyLength])
-
-		var newKey [ed.PublicKeySize]byte
-		newPubString := string(e.ExtIDs[2])
-		if IdentityKeyStringType(newPubString) != IDPub {
-			continue
-		}
-		b = base58.Decode(newPubString)
-		copy(newKey[:], b[IDKeyPrefixLength:IDKeyBodyLength])
-
-		// Disallow re-adding retired or currently active keys
-		if _, present := allKeys[newPubString]; present {
-			continue
-		}
-
-		var signature [ed.SignatureSize]byte
-		copy(signature[:], e.ExtIDs[3])
-		signerPubString := string(e.ExtIDs[4])
-
-		levelToReplace := -1
-		for level, key := range activeKeys {
-			if bytes.Compare(oldKey[:], key.PubBytes()) == 0 {
-				levelToReplace = level
-			}
-		}
-		if levelToReplace == -1 {
-			// oldkey not in the set of valid keys when this entry was published
-			continue
-		}
-
-		message := []byte(chainID + oldPubString + newPubString)
-		for level, key := range activeKeys {
-			if level > levelToReplace {
-				// low priority key trying to replace high priority key, disregard
-				break
-			}
-			if key.PubString() == signerPubString && ed.Verify(key.Pub, message, &signature) {
-				activeKeys[levelToReplace].Pub = &newKey
-				allKeys[newPubString] = true
-				break
-			}
-		}
+		s.applyKeyReplacement(e.Entry, e.Hash, e.Height)
 	}
 
-	var resp []string
-	for _, k := range activeKeys {
-		resp = append(resp, k.PubString())
-	}
-	return resp, nil
+	return s.keys, nil
 }
 
 // NewIdentityKeyReplacementEntry creates and returns a new Entry struct for the key replacement. Publish it to the
 // blockchain using the usual factom.CommitEntry(...) and factom.RevealEntry(...) calls.
 func NewIdentityKeyReplacementEntry(chainID string, oldKey string, newKey string, signerKey *IdentityKey) (*Entry, error) {
+	return NewIdentityKeyReplacementEntryWithSigner(chainID, oldKey, newKey, signerKey.Signer())
+}
+
+// NewIdentityKeyReplacementEntryWithSigner creates the key replacement Entry
+// like NewIdentityKeyReplacementEntry, signed by the Identity Key held by
+// signer.
+func NewIdentityKeyReplacementEntryWithSigner(chainID string, oldKey string, newKey string, signer Signer) (*Entry, error) {
 	if IdentityKeyStringType(oldKey) != IDPub {
 		return nil, fmt.Errorf("provided key %s is not a valid identity public key", oldKey)
 	}
@@ -183,7 +113,10 @@ func NewIdentityKeyReplacementEntry(chainID string, oldKey string, newKey string
 		return nil, fmt.Errorf("provided key %s is not a valid identity public key", newKey)
 	}
 	message := []byte(chainID + oldKey + newKey)
-	signature := signerKey.Sign(message)
+	signature, err := signWith(signer, message)
+	if err != nil {
+		return nil, err
+	}
 
 	e := Entry{}
 	e.ChainID = chainID
@@ -191,8 +124,8 @@ func NewIdentityKeyReplacementEntry(chainID string, oldKey string, newKey string
 		[]byte("ReplaceKey"),
 		[]byte(oldKey),
 		[]byte(newKey),
-		signature[:],
-		[]byte(signerKey.String()),
+		signature,
+		[]byte(SignerIdentityKey(signer)),
 	}
 	return &e, nil
 }
@@ -200,44 +133,66 @@ func NewIdentityKeyReplacementEntry(chainID string, oldKey string, newKey string
 // NewIdentityAttributeEntry creates and returns an Entry struct that assigns an attribute JSON object to a given
 // identity. Publish it to the blockchain using the usual factom.CommitEntry(...) and factom.RevealEntry(...) calls.
 func NewIdentityAttributeEntry(receiverChainID string, destinationChainID string, attributesJSON string, signerKey *IdentityKey, signerChainID string) *Entry {
+	e, _ := NewIdentityAttributeEntryWithSigner(receiverChainID, destinationChainID, attributesJSON, signerKey.Signer(), signerChainID)
+	return e
+}
+
+// NewIdentityAttributeEntryWithSigner creates the attribute Entry like
+// NewIdentityAttributeEntry, signed by the Identity Key held by signer.
+func NewIdentityAttributeEntryWithSigner(receiverChainID string, destinationChainID string, attributesJSON string, signer Signer, signerChainID string) (*Entry, error) {
 	message := []byte(receiverChainID + destinationChainID)
 	attributeHash := sha256.Sum256([]byte(attributesJSON))
 	message = append(message, attributeHash[:]...)
-	signature := signerKey.Sign(message)
+	signature, err := signWith(signer, message)
+	if err != nil {
+		return nil, err
+	}
 
 	e := Entry{}
 	e.ChainID = destinationChainID
 	e.ExtIDs = [][]byte{
 		[]byte("IdentityAttribute"),
 		[]byte(receiverChainID),
-		signature[:],
-		[]byte(signerKey.String()),
+		signature,
+		[]byte(SignerIdentityKey(signer)),
 		[]byte(signerChainID),
 	}
 	e.Content = []byte(attributesJSON)
-	return &e
+	return &e, nil
 }
 
 // NewIdentityAttributeEndorsementEntry creates and returns an Entry struct that agrees with or recognizes a given
 // attribute. Publish it to the blockchain using the usual factom.CommitEntry(...) and factom.RevealEntry(...) calls.
 func NewIdentityAttributeEndorsementEntry(destinationChainID string, attributeEntryHash string, signerKey *IdentityKey, signerChainID string) *Entry {
+	e, _ := NewIdentityAttributeEndorsementEntryWithSigner(destinationChainID, attributeEntryHash, signerKey.Signer(), signerChainID)
+	return e
+}
+
+// NewIdentityAttributeEndorsementEntryWithSigner creates the endorsement Entry
+// like NewIdentityAttributeEndorsementEntry, signed by the Identity Key held by
+// signer.
+func NewIdentityAttributeEndorsementEntryWithSigner(destinationChainID string, attributeEntryHash string, signer Signer, signerChainID string) (*Entry, error) {
 	message := []byte(destinationChainID + attributeEntryHash)
-	signature := signerKey.Sign(message)
+	signature, err := signWith(signer, message)
+	if err != nil {
+		return nil, err
+	}
 
 	e := Entry{}
 	e.ChainID = destinationChainID
 	e.ExtIDs = [][]byte{
 		[]byte("IdentityAttributeEndorsement"),
-		signature[:],
-		[]byte(signerKey.String()),
+		signature,
+		[]byte(SignerIdentityKey(signer)),
 		[]byte(signerChainID),
 	}
 	e.Content = []byte(attributeEntryHash)
-	return &e
+	return &e, nil
 }
 
 // IsValidAttribute returns true if the entry is a properly formatted attribute with a verifiable signature.
-// Note: does not check that the signer key was valid for the signer identity at the time of publishing.
+// Note: does not check that the signer key was valid for the signer identity at the time of publishing, see
+// ValidateAttribute.
 func IsValidAttribute(e *Entry) bool {
 	// Check ExtIDs for valid formatting, then process them
 	if len(e.ExtIDs) < 5 || bytes.Compare(e.ExtIDs[0], []byte("IdentityAttribute")) != 0 {
@@ -266,7 +221,8 @@ func IsValidAttribute(e *Entry) bool {
 }
 
 // IsValidEndorsement returns true if the Entry is a properly formatted attribute endorsement with a verifiable signature.
-// Note: does not check that the signer key was valid for the signer identity at the time of publishing.
+// Note: does not check that the signer key was valid for the signer identity at the time of publishing, see
+// ValidateEndorsement.
 func IsValidEndorsement(e *Entry) bool {
 	// Check ExtIDs for valid formatting, then process them
 	if len(e.ExtIDs) < 4 || string(e.ExtIDs[0]) != "IdentityAttributeEndorsement" {
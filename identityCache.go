@@ -0,0 +1,295 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// IdentityState is the key state of an identity chain up to an Entry Block.
+// The keys at any height up to Height can be computed from the initial keys and
+// the key history.
+type IdentityState struct {
+	ChainID       string                    `json:"chainid"`
+	Name          []string                  `json:"name"`
+	InitialKeys   []string                  `json:"initialkeys"`
+	KeyHistory    []*IdentityKeyReplacement `json:"keyhistory"`
+	CreatedHeight int64                     `json:"createdheight"`
+
+	// KeyMR and Height are the last Entry Block that was processed
+	KeyMR  string `json:"keymr"`
+	Height int64  `json:"height"`
+}
+
+// Keys returns the active keys after the last processed Entry Block.
+func (s *IdentityState) Keys() []string {
+	return s.KeysAtHeight(s.Height)
+}
+
+// KeysAtHeight returns the active keys at the block height.
+func (s *IdentityState) KeysAtHeight(height int64) []string {
+	keys := append([]string(nil), s.InitialKeys...)
+	for _, r := range s.KeyHistory {
+		if r.Height > height {
+			break
+		}
+		keys[r.Level] = r.NewKey
+	}
+	return keys
+}
+
+func (s *IdentityState) MarshalBinary() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+func (s *IdentityState) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, s)
+}
+
+func (s *IdentityState) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	return nil, s.UnmarshalBinary(data)
+}
+
+// identityState restores the replay state after the last processed Entry
+// Block.
+func (s *IdentityState) identityState() *identityState {
+	is := new(identityState)
+	is.chainID = s.ChainID
+	is.name = s.Name
+	is.initialKeys = s.InitialKeys
+	is.keys = s.Keys()
+	is.allKeys = make(map[string]bool)
+	for _, k := range s.InitialKeys {
+		is.allKeys[k] = true
+	}
+	for _, r := range s.KeyHistory {
+		is.allKeys[r.NewKey] = true
+	}
+	is.history = append([]*IdentityKeyReplacement(nil), s.KeyHistory...)
+	return is
+}
+
+// IdentityStateStore persists IdentityStates for an IdentityKeyCache.
+type IdentityStateStore interface {
+	// GetIdentityState returns the stored state of the identity, or nil if
+	// there is none.
+	GetIdentityState(chainID string) (*IdentityState, error)
+	InsertIdentityState(s *IdentityState) error
+	RemoveIdentityState(chainID string) error
+}
+
+// MemoryIdentityStateStore is an IdentityStateStore that is not persisted.
+type MemoryIdentityStateStore struct {
+	lock   sync.RWMutex
+	states map[string][]byte
+}
+
+func NewMemoryIdentityStateStore() *MemoryIdentityStateStore {
+	m := new(MemoryIdentityStateStore)
+	m.states = make(map[string][]byte)
+	return m
+}
+
+func (m *MemoryIdentityStateStore) GetIdentityState(chainID string) (*IdentityState, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	data, ok := m.states[chainID]
+	if !ok {
+		return nil, nil
+	}
+	s := new(IdentityState)
+	if err := s.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (m *MemoryIdentityStateStore) InsertIdentityState(s *IdentityState) error {
+	data, err := s.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.states[s.ChainID] = data
+	return nil
+}
+
+func (m *MemoryIdentityStateStore) RemoveIdentityState(chainID string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.states, chainID)
+	return nil
+}
+
+// IdentityKeyCache computes identity keys like GetActiveIdentityKeysAtHeight
+// but keeps the state of each identity in a store, so that only the Entry
+// Blocks added to an identity chain since it was last resolved are downloaded.
+// A stored state is discarded and the chain replayed if its last Entry Block
+// is no longer part of the chain.
+type IdentityKeyCache struct {
+	Store IdentityStateStore
+
+	lock sync.Mutex
+}
+
+// NewIdentityKeyCache returns an IdentityKeyCache using store, or a
+// MemoryIdentityStateStore if store is nil.
+func NewIdentityKeyCache(store IdentityStateStore) *IdentityKeyCache {
+	if store == nil {
+		store = NewMemoryIdentityStateStore()
+	}
+	c := new(IdentityKeyCache)
+	c.Store = store
+	return c
+}
+
+// GetActiveIdentityKeys returns the identity's public keys that are active at
+// the highest saved block height, along with that block height.
+func (c *IdentityKeyCache) GetActiveIdentityKeys(chainID string) ([]string, int64, error) {
+	heights, err := GetHeights()
+	if err != nil {
+		return nil, -1, err
+	}
+	keys, err := c.GetActiveIdentityKeysAtHeight(chainID, heights.DirectoryBlockHeight)
+	return keys, heights.DirectoryBlockHeight, err
+}
+
+// GetActiveIdentityKeysAtHeight returns the identity's public keys that were
+// active at the specified block height.
+func (c *IdentityKeyCache) GetActiveIdentityKeysAtHeight(chainID string, height int64) ([]string, error) {
+	s, err := c.Update(chainID)
+	if err == errUnknownIdentity {
+		return nil, fmt.Errorf("chain does not exist")
+	} else if err != nil {
+		return nil, err
+	}
+	if height < s.CreatedHeight {
+		return nil, fmt.Errorf("chain did not yet exist at height %d", height)
+	}
+	return s.KeysAtHeight(height), nil
+}
+
+// ValidateAttribute is ValidateAttribute with signer identities resolved
+// through the cache.
+func (c *IdentityKeyCache) ValidateAttribute(e *Entry, height int64) (*IdentityEntryVerdict, error) {
+	return validateAttribute(e, height, c.keysAt)
+}
+
+// ValidateEndorsement is ValidateEndorsement with signer identities resolved
+// through the cache.
+func (c *IdentityKeyCache) ValidateEndorsement(e *Entry, height int64) (*IdentityEntryVerdict, error) {
+	return validateEndorsement(e, height, c.keysAt)
+}
+
+func (c *IdentityKeyCache) keysAt(chainID string, height int64) ([]string, error) {
+	s, err := c.Update(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if height < s.CreatedHeight {
+		return nil, errUnknownIdentity
+	}
+	return s.KeysAtHeight(height), nil
+}
+
+// Invalidate removes the stored state of the identity.
+func (c *IdentityKeyCache) Invalidate(chainID string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.Store.RemoveIdentityState(chainID)
+}
+
+// Update brings the stored state of the identity up to the chain head and
+// returns it.
+func (c *IdentityKeyCache) Update(chainID string) (*IdentityState, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	head, err := GetChainHeadAndStatus(chainID)
+	if _, ok := err.(*JSONError); ok {
+		return nil, errUnknownIdentity
+	} else if err != nil {
+		return nil, err
+	} else if head.ChainHead == "" {
+		return nil, errUnknownIdentity
+	}
+
+	cached, err := c.Store.GetIdentityState(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && cached.ChainID != chainID {
+		cached = nil
+	}
+	if cached != nil && cached.KeyMR == head.ChainHead {
+		return cached, nil
+	}
+
+	// walk back to the last processed Entry Block
+	var keymrs []string
+	var ebs []*EBlock
+	for ebhash := head.ChainHead; ebhash != ZeroHash; {
+		if cached != nil && ebhash == cached.KeyMR {
+			break
+		}
+		eb, err := GetEBlock(ebhash)
+		if err != nil {
+			return nil, err
+		}
+		keymrs = append([]string{ebhash}, keymrs...)
+		ebs = append([]*EBlock{eb}, ebs...)
+		ebhash = eb.Header.PrevKeyMR
+	}
+	if cached != nil && len(ebs) > 0 && ebs[0].Header.PrevKeyMR != cached.KeyMR {
+		// the stored state is not part of this chain, replay from the start
+		cached = nil
+	}
+
+	var s *identityState
+	created := int64(0)
+	if cached != nil {
+		s = cached.identityState()
+		created = cached.CreatedHeight
+	}
+	for _, eb := range ebs {
+		for _, v := range eb.EntryList {
+			e, err := GetEntry(v.EntryHash)
+			if err != nil {
+				return nil, err
+			}
+			if s == nil {
+				if s, err = newIdentityState(chainID, e); err != nil {
+					c.Store.RemoveIdentityState(chainID)
+					return nil, errUnknownIdentity
+				}
+				created = eb.Header.DBHeight
+				continue
+			}
+			s.applyKeyReplacement(e, v.EntryHash, eb.Header.DBHeight)
+		}
+	}
+	if s == nil {
+		return nil, errUnknownIdentity
+	}
+
+	state := new(IdentityState)
+	state.ChainID = chainID
+	state.Name = s.name
+	state.InitialKeys = s.initialKeys
+	state.KeyHistory = s.history
+	state.CreatedHeight = created
+	state.KeyMR = keymrs[len(keymrs)-1]
+	state.Height = ebs[len(ebs)-1].Header.DBHeight
+
+	if err := c.Store.InsertIdentityState(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestIdentityKeyCache(t *testing.T) {
+	k := testIdentityKeys(t)
+	f := newFakeFactomd()
+
+	c, _ := NewIdentityChain([]string{"Cached"}, []string{k[0].PubString(), k[1].PubString()})
+	chainID := c.ChainID
+	f.addBlock(10, c.FirstEntry)
+	r1, _ := NewIdentityKeyReplacementEntry(chainID, k[1].PubString(), k[2].PubString(), k[0])
+	f.addBlock(20, r1)
+
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	SetFactomdServer(ts.URL[7:])
+
+	store := NewMemoryIdentityStateStore()
+	cache := NewIdentityKeyCache(store)
+
+	keys, err := cache.GetActiveIdentityKeysAtHeight(chainID, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys[1] != k[2].PubString() {
+		t.Errorf("expected the replacement key at height 20")
+	}
+	if f.calls["entry"] != 2 {
+		t.Errorf("expected 2 entries to be downloaded, found %d", f.calls["entry"])
+	}
+
+	// resolving again or at an earlier height only checks the chain head
+	f.calls = make(map[string]int)
+	keys, err = cache.GetActiveIdentityKeysAtHeight(chainID, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys[1] != k[1].PubString() {
+		t.Errorf("expected the initial key at height 15")
+	}
+	if f.calls["entry"] != 0 || f.calls["entry-block"] != 0 {
+		t.Errorf("expected no blocks to be downloaded, found %v", f.calls)
+	}
+
+	// only the new block is downloaded
+	r2, _ := NewIdentityKeyReplacementEntry(chainID, k[2].PubString(), k[3].PubString(), k[0])
+	f.addBlock(30, r2)
+	f.calls = make(map[string]int)
+	keys, err = cache.GetActiveIdentityKeysAtHeight(chainID, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys[1] != k[3].PubString() {
+		t.Errorf("expected the second replacement key at height 30")
+	}
+	if f.calls["entry"] != 1 || f.calls["entry-block"] != 1 {
+		t.Errorf("expected one new block to be downloaded, found %v", f.calls)
+	}
+	expected, err := GetActiveIdentityKeysAtHeight(chainID, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range expected {
+		if keys[i] != expected[i] {
+			t.Errorf("key %d: cached %s, expected %s", i, keys[i], expected[i])
+		}
+	}
+
+	if _, err := cache.GetActiveIdentityKeysAtHeight(chainID, 5); err == nil {
+		t.Error("expected an error before the identity existed")
+	}
+
+	// a different chain under the same chain ID replaces the stored state
+	g := newFakeFactomd()
+	g.addBlock(11, c.FirstEntry)
+	ts2 := httptest.NewServer(g)
+	defer ts2.Close()
+	SetFactomdServer(ts2.URL[7:])
+
+	keys, err = cache.GetActiveIdentityKeysAtHeight(chainID, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys[1] != k[1].PubString() {
+		t.Errorf("expected the stored state to be invalidated")
+	}
+	s, _ := store.GetIdentityState(chainID)
+	if s == nil || s.CreatedHeight != 11 || len(s.KeyHistory) != 0 {
+		t.Errorf("unexpected stored state %+v", s)
+	}
+}
@@ -146,17 +146,8 @@ func MakeIdentityKey(sec []byte) (*IdentityKey, error) {
 }
 
 func MakeBIP44IdentityKey(mnemonic string, account, chain, address uint32) (*IdentityKey, error) {
-	mnemonic, err := ParseAndValidateMnemonic(mnemonic)
-	if err != nil {
-		return nil, err
-	}
-
-	child, err := bip44.NewKeyFromMnemonic(mnemonic, bip44.TypeFactomIdentity, account, chain, address)
-	if err != nil {
-		return nil, err
-	}
-
-	return MakeIdentityKey(child.Key)
+	path := &DerivationPath{bip44.TypeFactomIdentity, account, chain, address}
+	return MakeIdentityKeyFromPath(mnemonic, "", path)
 }
 
 // PubBytes returns the []byte representation of the public key
@@ -0,0 +1,339 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/FactomProject/btcutil/base58"
+	ed "github.com/FactomProject/ed25519"
+)
+
+// IdentityKeyReplacement is a ReplaceKey entry that was applied to an
+// identity's keys.
+type IdentityKeyReplacement struct {
+	EntryHash   string `json:"entryhash"`
+	Height      int64  `json:"height"`
+	Level       int    `json:"level"`
+	OldKey      string `json:"oldkey"`
+	NewKey      string `json:"newkey"`
+	SignerKey   string `json:"signerkey"`
+	SignerLevel int    `json:"signerlevel"`
+}
+
+// IdentityAttributeEntry is a valid IdentityAttribute entry assigning
+// attributes to an identity.
+type IdentityAttributeEntry struct {
+	EntryHash       string                 `json:"entryhash"`
+	Height          int64                  `json:"height"`
+	ChainID         string                 `json:"chainid"`
+	ReceiverChainID string                 `json:"receiverchainid"`
+	SignerChainID   string                 `json:"signerchainid"`
+	SignerKey       string                 `json:"signerkey"`
+	Attributes      []IdentityAttribute    `json:"attributes"`
+	Endorsements    []*IdentityEndorsement `json:"endorsements"`
+}
+
+// IdentityEndorsement is a valid IdentityAttributeEndorsement entry.
+type IdentityEndorsement struct {
+	EntryHash          string `json:"entryhash"`
+	Height             int64  `json:"height"`
+	ChainID            string `json:"chainid"`
+	AttributeEntryHash string `json:"attributeentryhash"`
+	SignerChainID      string `json:"signerchainid"`
+	SignerKey          string `json:"signerkey"`
+}
+
+// heightEntry is a chain entry with its hash, the height of the Directory
+// Block that included it, and the time it was included in unix seconds.
+type heightEntry struct {
+	*Entry
+	Hash      string
+	Height    int64
+	Timestamp int64
+}
+
+// getChainEntriesWithHeights returns the entries of a chain up to and
+// including height, in chain order.
+func getChainEntriesWithHeights(chainID string, height int64) ([]*heightEntry, error) {
+	es := make([]*heightEntry, 0)
+
+	head, err := GetChainHeadAndStatus(chainID)
+	if err != nil {
+		return es, err
+	}
+
+	if head.ChainHead == "" && head.ChainInProcessList {
+		return nil, fmt.Errorf("Chain not yet included in a Directory Block")
+	}
+
+	for ebhash := head.ChainHead; ebhash != ZeroHash; {
+		eb, err := GetEBlock(ebhash)
+		if err != nil {
+			return es, err
+		}
+		if eb.Header.DBHeight > height {
+			ebhash = eb.Header.PrevKeyMR
+			continue
+		}
+
+		s := make([]*heightEntry, 0, len(eb.EntryList))
+		for _, v := range eb.EntryList {
+			e, err := GetEntry(v.EntryHash)
+			if err != nil {
+				return es, err
+			}
+			s = append(s, &heightEntry{e, v.EntryHash, eb.Header.DBHeight, v.Timestamp})
+		}
+		es = append(s, es...)
+
+		ebhash = eb.Header.PrevKeyMR
+	}
+
+	return es, nil
+}
+
+// identityState is the name and keys of an identity as ReplaceKey entries are
+// applied in chain order.
+type identityState struct {
+	chainID     string
+	name        []string
+	initialKeys []string
+	keys        []string
+	allKeys     map[string]bool
+	history     []*IdentityKeyReplacement
+}
+
+// newIdentityState reads the name and initial keys from the first entry of an
+// identity chain.
+func newIdentityState(chainID string, first *Entry) (*identityState, error) {
+	if len(first.ExtIDs) == 0 || bytes.Compare(first.ExtIDs[0], []byte("IdentityChain")) != 0 {
+		return nil, fmt.Errorf("no identity found at chain ID: %s", chainID)
+	}
+
+	var identityInfo struct {
+		Version     int      `json:"version"`
+		InitialKeys []string `json:"keys"`
+	}
+	if err := json.Unmarshal(first.Content, &identityInfo); err != nil {
+		return nil, fmt.Errorf("no identity found at chain ID: %s", chainID)
+	}
+
+	s := new(identityState)
+	s.chainID = chainID
+	for _, part := range first.ExtIDs[1:] {
+		s.name = append(s.name, string(part))
+	}
+	s.allKeys = make(map[string]bool)
+	for _, pubString := range identityInfo.InitialKeys {
+		if IdentityKeyStringType(pubString) != IDPub {
+			return nil, fmt.Errorf("invalid identity public key string in first entry: %s", pubString)
+		} else if _, present := s.allKeys[pubString]; present {
+			continue
+		}
+		s.keys = append(s.keys, pubString)
+		s.allKeys[pubString] = true
+	}
+	s.initialKeys = append([]string(nil), s.keys...)
+
+	return s, nil
+}
+
+// applyKeyReplacement applies e if it is a valid ReplaceKey entry for the
+// current keys and returns the replacement, or nil if e was disregarded.
+func (s *identityState) applyKeyReplacement(e *Entry, hash string, height int64) *IdentityKeyReplacement {
+	if len(e.ExtIDs) < 5 || bytes.Compare(e.ExtIDs[0], []byte("ReplaceKey")) != 0 {
+		return nil
+	}
+	if len(e.ExtIDs[1]) != 55 || len(e.ExtIDs[2]) != 55 || len(e.ExtIDs[3]) != ed.SignatureSize {
+		return nil
+	}
+
+	oldPubString := string(e.ExtIDs[1])
+	newPubString := string(e.ExtIDs[2])
+	if IdentityKeyStringType(oldPubString) != IDPub || IdentityKeyStringType(newPubString) != IDPub {
+		return nil
+	}
+
+	// Disallow re-adding retired or currently active keys
+	if _, present := s.allKeys[newPubString]; present {
+		return nil
+	}
+
+	var signature [ed.SignatureSize]byte
+	copy(signature[:], e.ExtIDs[3])
+	signerPubString := string(e.ExtIDs[4])
+
+	levelToReplace := -1
+	for level, key := range s.keys {
+		if key == oldPubString {
+			levelToReplace = level
+		}
+	}
+	if levelToReplace == -1 {
+		// oldkey not in the set of valid keys when this entry was published
+		return nil
+	}
+
+	message := []byte(s.chainID + oldPubString + newPubString)
+	for level, key := range s.keys {
+		if level > levelToReplace {
+			// low priority key trying to replace high priority key, disregard
+			break
+		}
+		if key != signerPubString {
+			continue
+		}
+		var signerKey [ed.PublicKeySize]byte
+		copy(signerKey[:], base58.Decode(key)[IDKeyPrefixLength:IDKeyBodyLength])
+		if !ed.Verify(&signerKey, message, &signature) {
+			break
+		}
+
+		s.keys[levelToReplace] = newPubString
+		s.allKeys[newPubString] = true
+
+		r := &IdentityKeyReplacement{
+			EntryHash:   hash,
+			Height:      height,
+			Level:       levelToReplace,
+			OldKey:      oldPubString,
+			NewKey:      newPubString,
+			SignerKey:   signerPubString,
+			SignerLevel: level,
+		}
+		s.history = append(s.history, r)
+		return r
+	}
+
+	return nil
+}
+
+// identityKeys returns the current keys as IdentityKeys holding only the public
+// key.
+func (s *identityState) identityKeys() []*IdentityKey {
+	keys := make([]*IdentityKey, 0, len(s.keys))
+	for _, pubString := range s.keys {
+		k := NewIdentityKey()
+		copy(k.Pub[:], base58.Decode(pubString)[IDKeyPrefixLength:IDKeyBodyLength])
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// GetIdentity resolves the identity at chainID as it was at the specified
+// block height: its name, the keys active at that height, every key
+// replacement applied up to that height, and the attributes and endorsements
+// in the identity chain that refer to it. Attributes and endorsements are
+// validated with ValidateAttribute and ValidateEndorsement at the height they
+// were published, and invalid ones, as well as attributes assigned to another
+// identity, are left out.
+func GetIdentity(chainID string, height int64) (*Identity, error) {
+	if !ChainExists(chainID) {
+		return nil, fmt.Errorf("chain does not exist")
+	}
+
+	entries, err := getChainEntriesWithHeights(chainID, height)
+	if err != nil {
+		return nil, err
+	} else if len(entries) == 0 {
+		return nil, fmt.Errorf("chain did not yet exist at height %d", height)
+	}
+
+	s, err := newIdentityState(chainID, entries[0].Entry)
+	if err != nil {
+		return nil, err
+	}
+
+	i := new(Identity)
+	i.ChainID = chainID
+	i.Name = s.name
+	i.Height = height
+
+	// the keys of this identity at each height are known from the replay, so
+	// only other signer identities are resolved from factomd
+	snapshots := make(map[int64][]string)
+	for _, e := range entries {
+		s.applyKeyReplacement(e.Entry, e.Hash, e.Height)
+		snapshots[e.Height] = append([]string(nil), s.keys...)
+	}
+	keysAt := cachedIdentityKeys(func(signerChainID string, h int64) ([]string, error) {
+		if keys, ok := snapshots[h]; ok && signerChainID == chainID {
+			return keys, nil
+		}
+		return fetchIdentityKeys(signerChainID, h)
+	})
+
+	attributes := make(map[string]*IdentityAttributeEntry)
+	for _, e := range entries {
+		if len(e.ExtIDs) == 0 {
+			continue
+		}
+		switch string(e.ExtIDs[0]) {
+		case "IdentityAttribute":
+			v, err := validateAttribute(e.Entry, e.Height, keysAt)
+			if err != nil {
+				return nil, err
+			}
+			if !v.Valid() || string(e.ExtIDs[1]) != chainID {
+				continue
+			}
+			a, err := newIdentityAttributeEntry(e)
+			if err != nil {
+				continue
+			}
+			i.Attributes = append(i.Attributes, a)
+			attributes[a.EntryHash] = a
+		case "IdentityAttributeEndorsement":
+			v, err := validateEndorsement(e.Entry, e.Height, keysAt)
+			if err != nil {
+				return nil, err
+			}
+			if !v.Valid() {
+				continue
+			}
+			d := newIdentityEndorsement(e)
+			i.Endorsements = append(i.Endorsements, d)
+			if a, ok := attributes[d.AttributeEntryHash]; ok {
+				a.Endorsements = append(a.Endorsements, d)
+			}
+		}
+	}
+
+	i.Keys = s.identityKeys()
+	i.KeyHistory = s.history
+	return i, nil
+}
+
+// newIdentityAttributeEntry reads a valid IdentityAttribute entry.
+func newIdentityAttributeEntry(e *heightEntry) (*IdentityAttributeEntry, error) {
+	a := &IdentityAttributeEntry{
+		EntryHash:       e.Hash,
+		Height:          e.Height,
+		ChainID:         e.ChainID,
+		ReceiverChainID: string(e.ExtIDs[1]),
+		SignerChainID:   string(e.ExtIDs[4]),
+		SignerKey:       string(e.ExtIDs[3]),
+		Endorsements:    make([]*IdentityEndorsement, 0),
+	}
+	if err := json.Unmarshal(e.Content, &a.Attributes); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// newIdentityEndorsement reads a valid IdentityAttributeEndorsement entry.
+func newIdentityEndorsement(e *heightEntry) *IdentityEndorsement {
+	return &IdentityEndorsement{
+		EntryHash:          e.Hash,
+		Height:             e.Height,
+		ChainID:            e.ChainID,
+		AttributeEntryHash: string(e.Content),
+		SignerChainID:      string(e.ExtIDs[3]),
+		SignerKey:          string(e.ExtIDs[2]),
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factom"
+)
+
+// fakeFactomd serves the chain-head, entry-block, and entry calls for chains
+// built in memory.
+type fakeFactomd struct {
+	heads   map[string]string
+	eblocks map[string]*EBlock
+	entries map[string]*Entry
+	// blockOf is the Entry Block KeyMR of each entry
+	blockOf map[string]string
+	height  int64
+	calls   map[string]int
+}
+
+func newFakeFactomd() *fakeFactomd {
+	f := new(fakeFactomd)
+	f.heads = make(map[string]string)
+	f.eblocks = make(map[string]*EBlock)
+	f.entries = make(map[string]*Entry)
+	f.blockOf = make(map[string]string)
+	f.calls = make(map[string]int)
+	return f
+}
+
+// addBlock adds an Entry Block with the entries to the head of their chain.
+func (f *fakeFactomd) addBlock(height int64, es ...*Entry) {
+	f.addBlockAt(height, time.Now(), es...)
+}
+
+// addBlockAt adds an Entry Block with the block time ts.
+func (f *fakeFactomd) addBlockAt(height int64, ts time.Time, es ...*Entry) {
+	chainID := es[0].ChainID
+
+	eb := new(EBlock)
+	eb.Header.ChainID = chainID
+	eb.Header.DBHeight = height
+	eb.Header.Timestamp = ts.Unix()
+	eb.Header.PrevKeyMR = ZeroHash
+	if head, ok := f.heads[chainID]; ok {
+		eb.Header.PrevKeyMR = head
+	}
+	for _, e := range es {
+		hash := hex.EncodeToString(e.Hash())
+		f.entries[hash] = e
+		eb.EntryList = append(eb.EntryList, EBEntry{EntryHash: hash, Timestamp: ts.Unix()})
+	}
+
+	keymr := sha256.Sum256([]byte(fmt.Sprintf("%s%d", chainID, height)))
+	f.eblocks[hex.EncodeToString(keymr[:])] = eb
+	f.heads[chainID] = hex.EncodeToString(keymr[:])
+	for _, v := range eb.EntryList {
+		f.blockOf[v.EntryHash] = hex.EncodeToString(keymr[:])
+	}
+	if height > f.height {
+		f.height = height
+	}
+}
+
+func (f *fakeFactomd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		Method string `json:"method"`
+		Params struct {
+			ChainID string `json:"chainid"`
+			KeyMR   string `json:"keymr"`
+			Hash    string `json:"hash"`
+		} `json:"params"`
+	})
+	json.NewDecoder(r.Body).Decode(req)
+	f.calls[req.Method]++
+
+	var result interface{}
+	switch req.Method {
+	case "chain-head":
+		if head, ok := f.heads[req.Params.ChainID]; ok {
+			result = map[string]interface{}{"chainhead": head, "chaininprocesslist": false}
+		}
+	case "entry-block":
+		if eb, ok := f.eblocks[req.Params.KeyMR]; ok {
+			result = eb
+		}
+	case "entry":
+		if e, ok := f.entries[req.Params.Hash]; ok {
+			result = e
+		}
+	case "receipt":
+		if keymr, ok := f.blockOf[req.Params.Hash]; ok {
+			result = map[string]interface{}{"receipt": map[string]string{"entryblockkeymr": keymr}}
+		}
+	case "heights":
+		result = &HeightsResponse{DirectoryBlockHeight: f.height, LeaderHeight: f.height}
+	}
+
+	resp := NewJSON2Response()
+	if result == nil {
+		resp.Error = NewJSONError(-32009, "Missing Chain Head", nil)
+	} else {
+		resp.Result, _ = json.Marshal(result)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func testIdentityKeys(t *testing.T) []*IdentityKey {
+	var keys []*IdentityKey
+	for _, s := range []string{
+		"idsec2rChEHLz3SPQQx3syQtB11pHAmxyGjux5FntnS7xqTCieHxxTc",
+		"idsec1xuUyeCCrJhsojf2wLAZqRxPzPFR8Gidd9DRRid1yGy8ncAJG3",
+		"idsec2J3nNoqdiyboCBKDGauqN9Jb33dyFSqaJKZqTs6i5FmztsTn5f",
+		"idsec1jztZ7dypqtwtPPWxybZFNpvvpUh6g8oog6Mnk2gGCm1pNBTgE",
+		"idsec2wH72BNR9QZhTMGDbxwLWGrghZQexZvLTros2wCekkc62N9h7s",
+	} {
+		k, err := GetIdentityKey(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestGetIdentity(t *testing.T) {
+	k := testIdentityKeys(t)
+	f := newFakeFactomd()
+
+	c, err := NewIdentityChain([]string{"Test", "Identity"}, []string{k[0].PubString(), k[1].PubString(), k[2].PubString()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := c.ChainID
+	f.addBlock(10, c.FirstEntry)
+
+	// k1 replaces k2 with k3, and k2 may not replace the higher priority k0
+	r1, _ := NewIdentityKeyReplacementEntry(chainID, k[2].PubString(), k[3].PubString(), k[1])
+	bad, _ := NewIdentityKeyReplacementEntry(chainID, k[0].PubString(), k[4].PubString(), k[1])
+	attr := NewIdentityAttributeEntry(chainID, chainID, `[{"key":"email","value":"test@example.com"}]`, k[0], chainID)
+	attrHash := hex.EncodeToString(attr.Hash())
+	end := NewIdentityAttributeEndorsementEntry(chainID, attrHash, k[1], chainID)
+	other := NewIdentityAttributeEntry(GetIdentityChainID([]string{"other"}), chainID, `[{"key":"a","value":"b"}]`, k[0], chainID)
+	f.addBlock(20, r1, bad, attr, end, other)
+
+	r2, _ := NewIdentityKeyReplacementEntry(chainID, k[1].PubString(), k[4].PubString(), k[0])
+	f.addBlock(30, r2)
+
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	SetFactomdServer(ts.URL[7:])
+
+	id, err := GetIdentity(chainID, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id.Name) != 2 || id.Name[0] != "Test" || id.Name[1] != "Identity" {
+		t.Errorf("unexpected name %v", id.Name)
+	}
+	expected := []string{k[0].PubString(), k[1].PubString(), k[3].PubString()}
+	if len(id.Keys) != len(expected) {
+		t.Fatalf("expected %d keys, found %d", len(expected), len(id.Keys))
+	}
+	for i, key := range id.Keys {
+		if key.PubString() != expected[i] {
+			t.Errorf("key %d: expected %s, found %s", i, expected[i], key.PubString())
+		}
+	}
+	if len(id.KeyHistory) != 1 {
+		t.Fatalf("expected 1 key replacement, found %d", len(id.KeyHistory))
+	}
+	if h := id.KeyHistory[0]; h.Height != 20 || h.Level != 2 || h.SignerKey != k[1].PubString() || h.NewKey != k[3].PubString() {
+		t.Errorf("unexpected key replacement %+v", h)
+	}
+	if len(id.Attributes) != 1 {
+		t.Fatalf("expected 1 attribute entry, found %d", len(id.Attributes))
+	}
+	if a := id.Attributes[0]; a.EntryHash != attrHash || len(a.Attributes) != 1 || a.Attributes[0].Key != "email" {
+		t.Errorf("unexpected attribute entry %+v", a)
+	}
+	if len(id.Attributes[0].Endorsements) != 1 || len(id.Endorsements) != 1 {
+		t.Errorf("expected 1 endorsement")
+	}
+
+	id, err = GetIdentity(chainID, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id.KeyHistory) != 2 || id.Keys[1].PubString() != k[4].PubString() {
+		t.Errorf("expected the second replacement to be applied at height 30")
+	}
+
+	keys, err := GetActiveIdentityKeysAtHeight(chainID, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range id.Keys {
+		if keys[i] != key.PubString() {
+			t.Errorf("active key %d: expected %s, found %s", i, key.PubString(), keys[i])
+		}
+	}
+
+	if _, err := GetIdentity(chainID, 5); err == nil {
+		t.Error("expected an error before the identity existed")
+	}
+}
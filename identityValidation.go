@@ -0,0 +1,186 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"errors"
+	"fmt"
+)
+
+// IdentityEntryStatus is the result of validating a signed identity entry
+// against the signer identity.
+type IdentityEntryStatus int
+
+const (
+	// IdentityEntryValid means the signature verifies and the signer key was
+	// active for the signer identity when the entry was published.
+	IdentityEntryValid IdentityEntryStatus = iota
+	// IdentityEntryBadSignature means the entry is malformed or its signature
+	// does not verify.
+	IdentityEntryBadSignature
+	// IdentityEntrySignerKeyNotActive means the signature verifies but the
+	// signer key was not an active key of the signer identity at the height.
+	IdentityEntrySignerKeyNotActive
+	// IdentityEntryUnknownSigner means the signer chain is not an identity
+	// chain at the height.
+	IdentityEntryUnknownSigner
+)
+
+func (s IdentityEntryStatus) String() string {
+	switch s {
+	case IdentityEntryValid:
+		return "valid"
+	case IdentityEntryBadSignature:
+		return "bad signature"
+	case IdentityEntrySignerKeyNotActive:
+		return "signer key not active"
+	case IdentityEntryUnknownSigner:
+		return "unknown signer chain"
+	}
+	return fmt.Sprintf("unknown status %d", int(s))
+}
+
+// MarshalText encodes the status as its string.
+func (s IdentityEntryStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// IdentityEntryVerdict is the result of validating an attribute or
+// endorsement entry at a block height.
+type IdentityEntryVerdict struct {
+	Status        IdentityEntryStatus `json:"status"`
+	Height        int64               `json:"height"`
+	SignerChainID string              `json:"signerchainid"`
+	SignerKey     string              `json:"signerkey"`
+	// SignerLevel is the priority of the signer key in the signer identity,
+	// or -1 if the key was not active.
+	SignerLevel int `json:"signerlevel"`
+}
+
+// Valid returns true if the entry is valid.
+func (v *IdentityEntryVerdict) Valid() bool {
+	return v.Status == IdentityEntryValid
+}
+
+// errUnknownIdentity is returned by an identityKeysFunc for a chain that is
+// not an identity at the height.
+var errUnknownIdentity = errors.New("factom: unknown identity")
+
+// identityKeysFunc returns the active keys of the identity at chainID at
+// height, or errUnknownIdentity.
+type identityKeysFunc func(chainID string, height int64) ([]string, error)
+
+// fetchIdentityKeys returns the active keys of an identity from factomd.
+func fetchIdentityKeys(chainID string, height int64) ([]string, error) {
+	head, err := GetChainHeadAndStatus(chainID)
+	if _, ok := err.(*JSONError); ok {
+		// factomd does not know the chain
+		return nil, errUnknownIdentity
+	} else if err != nil {
+		return nil, err
+	} else if head.ChainHead == "" {
+		return nil, errUnknownIdentity
+	}
+
+	entries, err := getChainEntriesWithHeights(chainID, height)
+	if err != nil {
+		return nil, err
+	} else if len(entries) == 0 {
+		return nil, errUnknownIdentity
+	}
+
+	s, err := newIdentityState(chainID, entries[0].Entry)
+	if err != nil {
+		return nil, errUnknownIdentity
+	}
+	for _, e := range entries {
+		s.applyKeyReplacement(e.Entry, e.Hash, e.Height)
+	}
+	return s.keys, nil
+}
+
+// cachedIdentityKeys wraps f so that each identity is only resolved once per
+// height.
+func cachedIdentityKeys(f identityKeysFunc) identityKeysFunc {
+	type key struct {
+		chainID string
+		height  int64
+	}
+	cache := make(map[key][]string)
+	return func(chainID string, height int64) ([]string, error) {
+		if keys, ok := cache[key{chainID, height}]; ok {
+			if keys == nil {
+				return nil, errUnknownIdentity
+			}
+			return keys, nil
+		}
+		keys, err := f(chainID, height)
+		if err == errUnknownIdentity {
+			cache[key{chainID, height}] = nil
+		} else if err == nil {
+			cache[key{chainID, height}] = keys
+		}
+		return keys, err
+	}
+}
+
+// ValidateAttribute validates an IdentityAttribute entry that was published
+// at the block height. Unlike IsValidAttribute it resolves the signer identity
+// to check that the signer key was active when the entry was published. An
+// error is only returned if the signer identity could not be resolved.
+func ValidateAttribute(e *Entry, height int64) (*IdentityEntryVerdict, error) {
+	return validateAttribute(e, height, fetchIdentityKeys)
+}
+
+// ValidateEndorsement validates an IdentityAttributeEndorsement entry that
+// was published at the block height. Unlike IsValidEndorsement it resolves the
+// signer identity to check that the signer key was active when the entry was
+// published. An error is only returned if the signer identity could not be
+// resolved.
+func ValidateEndorsement(e *Entry, height int64) (*IdentityEntryVerdict, error) {
+	return validateEndorsement(e, height, fetchIdentityKeys)
+}
+
+func validateAttribute(e *Entry, height int64, keysAt identityKeysFunc) (*IdentityEntryVerdict, error) {
+	v := &IdentityEntryVerdict{Status: IdentityEntryBadSignature, Height: height, SignerLevel: -1}
+	if !IsValidAttribute(e) {
+		return v, nil
+	}
+	v.SignerKey = string(e.ExtIDs[3])
+	v.SignerChainID = string(e.ExtIDs[4])
+	return v, checkSignerKey(v, keysAt)
+}
+
+func validateEndorsement(e *Entry, height int64, keysAt identityKeysFunc) (*IdentityEntryVerdict, error) {
+	v := &IdentityEntryVerdict{Status: IdentityEntryBadSignature, Height: height, SignerLevel: -1}
+	if !IsValidEndorsement(e) {
+		return v, nil
+	}
+	v.SignerKey = string(e.ExtIDs[2])
+	v.SignerChainID = string(e.ExtIDs[3])
+	return v, checkSignerKey(v, keysAt)
+}
+
+// checkSignerKey sets the status of v from the keys of the signer identity at
+// the height of v.
+func checkSignerKey(v *IdentityEntryVerdict, keysAt identityKeysFunc) error {
+	keys, err := keysAt(v.SignerChainID, v.Height)
+	if err == errUnknownIdentity {
+		v.Status = IdentityEntryUnknownSigner
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	v.Status = IdentityEntrySignerKeyNotActive
+	for level, key := range keys {
+		if key == v.SignerKey {
+			v.Status = IdentityEntryValid
+			v.SignerLevel = level
+			break
+		}
+	}
+	return nil
+}
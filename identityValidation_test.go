@@ -0,0 +1,71 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"encoding/hex"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestValidateAttribute(t *testing.T) {
+	k := testIdentityKeys(t)
+	f := newFakeFactomd()
+
+	signer, _ := NewIdentityChain([]string{"Signer"}, []string{k[0].PubString(), k[1].PubString()})
+	receiver, _ := NewIdentityChain([]string{"Receiver"}, []string{k[2].PubString()})
+	f.addBlock(10, signer.FirstEntry)
+	f.addBlock(10, receiver.FirstEntry)
+
+	// k1 is replaced by k3 at height 20
+	r, _ := NewIdentityKeyReplacementEntry(signer.ChainID, k[1].PubString(), k[3].PubString(), k[0])
+	f.addBlock(20, r)
+
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	SetFactomdServer(ts.URL[7:])
+
+	attr := func(key *IdentityKey, signerChainID string) *Entry {
+		return NewIdentityAttributeEntry(receiver.ChainID, receiver.ChainID, `[{"key":"kyc","value":true}]`, key, signerChainID)
+	}
+	tampered := attr(k[0], signer.ChainID)
+	tampered.Content = []byte(`[{"key":"kyc","value":false}]`)
+
+	tests := []struct {
+		name   string
+		e      *Entry
+		height int64
+		status IdentityEntryStatus
+		level  int
+	}{
+		{"active key", attr(k[0], signer.ChainID), 25, IdentityEntryValid, 0},
+		{"key before retirement", attr(k[1], signer.ChainID), 15, IdentityEntryValid, 1},
+		{"retired key", attr(k[1], signer.ChainID), 25, IdentityEntrySignerKeyNotActive, -1},
+		{"key before activation", attr(k[3], signer.ChainID), 15, IdentityEntrySignerKeyNotActive, -1},
+		{"replacement key", attr(k[3], signer.ChainID), 20, IdentityEntryValid, 1},
+		{"bad signature", tampered, 25, IdentityEntryBadSignature, -1},
+		{"unknown signer", attr(k[0], GetIdentityChainID([]string{"nobody"})), 25, IdentityEntryUnknownSigner, -1},
+		{"signer not yet created", attr(k[0], signer.ChainID), 5, IdentityEntryUnknownSigner, -1},
+	}
+	for _, tt := range tests {
+		v, err := ValidateAttribute(tt.e, tt.height)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if v.Status != tt.status || v.SignerLevel != tt.level {
+			t.Errorf("%s: expected %s at level %d, found %s at level %d", tt.name, tt.status, tt.level, v.Status, v.SignerLevel)
+		}
+	}
+
+	end := NewIdentityAttributeEndorsementEntry(receiver.ChainID, hex.EncodeToString(attr(k[0], signer.ChainID).Hash()), k[1], signer.ChainID)
+	if v, err := ValidateEndorsement(end, 15); err != nil || !v.Valid() {
+		t.Errorf("expected a valid endorsement, found %v %v", v, err)
+	}
+	if v, err := ValidateEndorsement(end, 25); err != nil || v.Status != IdentityEntrySignerKeyNotActive {
+		t.Errorf("expected an inactive signer key, found %v %v", v, err)
+	}
+}
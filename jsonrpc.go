@@ -196,6 +196,16 @@ func SendFactomdRequest(req *JSON2Request) (*JSON2Response, error) {
 }
 
 func factomdRequest(req *JSON2Request) (*JSON2Response, error) {
+	err := factomdCompat.run(RpcConfig.FactomdServer, CheckFactomdCompatibility)
+	if err != nil {
+		return nil, err
+	}
+	return sendFactomdRequest(req)
+}
+
+// sendFactomdRequest sends the request to factomd without checking the API
+// compatibility first.
+func sendFactomdRequest(req *JSON2Request) (*JSON2Response, error) {
 	j, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
@@ -265,6 +275,16 @@ func factomdRequest(req *JSON2Request) (*JSON2Response, error) {
 }
 
 func walletRequest(req *JSON2Request) (*JSON2Response, error) {
+	err := walletCompat.run(RpcConfig.WalletServer, CheckWalletCompatibility)
+	if err != nil {
+		return nil, err
+	}
+	return sendWalletRequest(req)
+}
+
+// sendWalletRequest sends the request to factom-walletd without checking the
+// API compatibility first.
+func sendWalletRequest(req *JSON2Request) (*JSON2Response, error) {
 	j, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,242 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/FactomProject/btcutil/base58"
+	"golang.org/x/crypto/scrypt"
+)
+
+// An encrypted key string holds a 32 byte secret key encrypted with
+// AES-256-GCM under a key derived from a password with scrypt. The prefix is
+// chosen so that the strings start with Fenc, Eenc, or idenc.
+//
+//	prefix | scrypt logN, r, p (3 bytes) | salt (16 bytes) | nonce (12 bytes) |
+//	encrypted secret and tag (48 bytes) | checksum (4 bytes)
+//
+// The prefix, scrypt parameters, and salt are authenticated with the secret.
+
+type encryptedKeyType byte
+
+const (
+	InvalidEncryptedKey encryptedKeyType = iota
+	FactoidSecEncrypted
+	ECSecEncrypted
+	IDSecEncrypted
+)
+
+const (
+	encKeySaltLength   = 16
+	encKeyNonceLength  = 12
+	encKeyParamsLength = 3
+	encKeyBodyLength   = encKeyParamsLength + encKeySaltLength + encKeyNonceLength + 32 + 16
+
+	// the maximum scrypt cost accepted for a key. scrypt uses 128*r*N bytes
+	// of memory and its running time grows with r*p.
+	encKeyMaxLogN   = 20
+	encKeyMaxMemory = 1 << 30
+	encKeyMaxRP     = 16
+)
+
+var (
+	fcEncPrefix = []byte{0x02, 0x63, 0xba, 0xf0}
+	ecEncPrefix = []byte{0x02, 0x39, 0xfa, 0x6a}
+	idEncPrefix = []byte{0x01, 0x89, 0xd0, 0xbd, 0x98}
+)
+
+// KeyEncryptionLogN, KeyEncryptionR, and KeyEncryptionP are the scrypt
+// parameters used for new encrypted key strings. The parameters are stored in
+// the string so that older keys can still be decrypted if they change.
+var (
+	KeyEncryptionLogN uint8 = 15
+	KeyEncryptionR    uint8 = 8
+	KeyEncryptionP    uint8 = 1
+)
+
+// EncryptedKey is a public address or Identity Key with its secret key in the
+// encrypted key format.
+type EncryptedKey struct {
+	Public string `json:"public"`
+	Secret string `json:"secret"`
+}
+
+var ErrKeyPassword = errors.New("factom: incorrect password for the encrypted key")
+
+// EncryptedKeyStringType returns the type of secret held by an encrypted key
+// string, or InvalidEncryptedKey if the string is not a valid encrypted key.
+func EncryptedKeyStringType(s string) encryptedKeyType {
+	p := base58.Decode(s)
+
+	var t encryptedKeyType
+	var prefix []byte
+	switch {
+	case bytes.HasPrefix(p, fcEncPrefix):
+		t, prefix = FactoidSecEncrypted, fcEncPrefix
+	case bytes.HasPrefix(p, ecEncPrefix):
+		t, prefix = ECSecEncrypted, ecEncPrefix
+	case bytes.HasPrefix(p, idEncPrefix):
+		t, prefix = IDSecEncrypted, idEncPrefix
+	default:
+		return InvalidEncryptedKey
+	}
+
+	if len(p) != len(prefix)+encKeyBodyLength+ChecksumLength {
+		return InvalidEncryptedKey
+	}
+
+	// verify the checksum
+	body := p[:len(p)-ChecksumLength]
+	check := p[len(p)-ChecksumLength:]
+	if !bytes.Equal(shad(body)[:ChecksumLength], check) {
+		return InvalidEncryptedKey
+	}
+
+	return t
+}
+
+// EncryptedSecString returns the secret key encrypted with password.
+func (a *FactoidAddress) EncryptedSecString(password string) (string, error) {
+	return encryptKey(fcEncPrefix, a.SecBytes()[:32], password)
+}
+
+// EncryptedSecString returns the secret key encrypted with password.
+func (a *ECAddress) EncryptedSecString(password string) (string, error) {
+	return encryptKey(ecEncPrefix, a.SecBytes()[:32], password)
+}
+
+// EncryptedSecString returns the secret key encrypted with password.
+func (k *IdentityKey) EncryptedSecString(password string) (string, error) {
+	return encryptKey(idEncPrefix, k.SecBytes()[:32], password)
+}
+
+// GetEncryptedFactoidAddress decrypts an encrypted key string (Fenc...) with
+// password and returns the FactoidAddress.
+func GetEncryptedFactoidAddress(s, password string) (*FactoidAddress, error) {
+	sec, err := decryptKey(s, FactoidSecEncrypted, password)
+	if err != nil {
+		return nil, err
+	}
+	return MakeFactoidAddress(sec)
+}
+
+// GetEncryptedECAddress decrypts an encrypted key string (Eenc...) with
+// password and returns the ECAddress.
+func GetEncryptedECAddress(s, password string) (*ECAddress, error) {
+	sec, err := decryptKey(s, ECSecEncrypted, password)
+	if err != nil {
+		return nil, err
+	}
+	return MakeECAddress(sec)
+}
+
+// GetEncryptedIdentityKey decrypts an encrypted key string (idenc...) with
+// password and returns the IdentityKey.
+func GetEncryptedIdentityKey(s, password string) (*IdentityKey, error) {
+	sec, err := decryptKey(s, IDSecEncrypted, password)
+	if err != nil {
+		return nil, err
+	}
+	return MakeIdentityKey(sec)
+}
+
+func encryptKey(prefix, sec []byte, password string) (string, error) {
+	if password == "" {
+		return "", fmt.Errorf("a password is required to encrypt the key")
+	}
+
+	if err := checkKeyEncryptionParams(KeyEncryptionLogN, KeyEncryptionR, KeyEncryptionP); err != nil {
+		return "", err
+	}
+
+	buf := new(bytes.Buffer)
+	buf.Write(prefix)
+	buf.Write([]byte{KeyEncryptionLogN, KeyEncryptionR, KeyEncryptionP})
+
+	salt := make([]byte, encKeySaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	buf.Write(salt)
+
+	nonce := make([]byte, encKeyNonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	aead, err := keyEncryptionCipher(password, salt, KeyEncryptionLogN, KeyEncryptionR, KeyEncryptionP)
+	if err != nil {
+		return "", err
+	}
+	ciphertext := aead.Seal(nil, nonce, sec, buf.Bytes())
+	buf.Write(nonce)
+	buf.Write(ciphertext)
+
+	check := shad(buf.Bytes())[:ChecksumLength]
+	buf.Write(check)
+
+	return base58.Encode(buf.Bytes()), nil
+}
+
+func decryptKey(s string, t encryptedKeyType, password string) ([]byte, error) {
+	if EncryptedKeyStringType(s) != t {
+		return nil, fmt.Errorf("Invalid Encrypted Key")
+	}
+
+	p := base58.Decode(s)
+	body := p[len(p)-ChecksumLength-encKeyBodyLength : len(p)-ChecksumLength]
+
+	params := body[:encKeyParamsLength]
+	logN, r, par := params[0], params[1], params[2]
+	if err := checkKeyEncryptionParams(logN, r, par); err != nil {
+		return nil, err
+	}
+
+	salt := body[encKeyParamsLength : encKeyParamsLength+encKeySaltLength]
+	nonce := body[encKeyParamsLength+encKeySaltLength : encKeyParamsLength+encKeySaltLength+encKeyNonceLength]
+	ciphertext := body[encKeyParamsLength+encKeySaltLength+encKeyNonceLength:]
+	header := p[:len(p)-ChecksumLength-len(nonce)-len(ciphertext)]
+
+	aead, err := keyEncryptionCipher(password, salt, logN, r, par)
+	if err != nil {
+		return nil, err
+	}
+	sec, err := aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, ErrKeyPassword
+	}
+
+	return sec, nil
+}
+
+// checkKeyEncryptionParams returns an error if the scrypt parameters are
+// invalid or would take too much memory or time to derive a key.
+func checkKeyEncryptionParams(logN, r, p uint8) error {
+	if logN == 0 || logN > encKeyMaxLogN || r == 0 || p == 0 {
+		return fmt.Errorf("Invalid Encrypted Key parameters")
+	}
+	if 128*uint64(r)<<logN > encKeyMaxMemory || int(r)*int(p) > encKeyMaxRP {
+		return fmt.Errorf("Encrypted Key parameters exceed the scrypt limits")
+	}
+	return nil
+}
+
+func keyEncryptionCipher(password string, salt []byte, logN, r, p uint8) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(password), salt, 1<<logN, int(r), int(p), 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
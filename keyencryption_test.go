@@ -0,0 +1,124 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/FactomProject/btcutil/base58"
+	. "github.com/FactomProject/factom"
+)
+
+func TestEncryptedSecString(t *testing.T) {
+	fa, _ := GetFactoidAddress("Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj")
+	ec, _ := GetECAddress("Es2Rf7iM6PdsqfYCo3D1tnAR65SkLENyWJG1deUzpRMQmbh9F3eG")
+	id, _ := GetIdentityKey("idsec2rWrfNTD1x9HPPesA3fz8dmMNZdjmSBULHx8VTXE1J4D9icmAK")
+
+	fs, err := fa.EncryptedSecString("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(fs, "Fenc") || EncryptedKeyStringType(fs) != FactoidSecEncrypted {
+		t.Errorf("invalid encrypted Factoid key %s", fs)
+	}
+	if f2, err := GetEncryptedFactoidAddress(fs, "password"); err != nil {
+		t.Error(err)
+	} else if f2.SecString() != fa.SecString() {
+		t.Errorf("decrypted key %s does not match", f2.SecString())
+	}
+
+	es, err := ec.EncryptedSecString("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(es, "Eenc") || EncryptedKeyStringType(es) != ECSecEncrypted {
+		t.Errorf("invalid encrypted Entry Credit key %s", es)
+	}
+	if e2, err := GetEncryptedECAddress(es, "password"); err != nil {
+		t.Error(err)
+	} else if e2.SecString() != ec.SecString() {
+		t.Errorf("decrypted key %s does not match", e2.SecString())
+	}
+
+	is, err := id.EncryptedSecString("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(is, "idenc") || EncryptedKeyStringType(is) != IDSecEncrypted {
+		t.Errorf("invalid encrypted identity key %s", is)
+	}
+	if k2, err := GetEncryptedIdentityKey(is, "password"); err != nil {
+		t.Error(err)
+	} else if k2.SecString() != id.SecString() {
+		t.Errorf("decrypted key %s does not match", k2.SecString())
+	}
+
+	// each encryption uses a new salt and nonce
+	if fs2, _ := fa.EncryptedSecString("password"); fs2 == fs {
+		t.Error("encrypting the same key twice gave the same result")
+	}
+}
+
+func TestEncryptedSecStringErrors(t *testing.T) {
+	fa, _ := GetFactoidAddress("Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj")
+
+	if _, err := fa.EncryptedSecString(""); err == nil {
+		t.Error("expected an error for an empty password")
+	}
+
+	fs, err := fa.EncryptedSecString("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetEncryptedFactoidAddress(fs, "wrong"); err != ErrKeyPassword {
+		t.Errorf("expected ErrKeyPassword, got %v", err)
+	}
+	if _, err := GetEncryptedECAddress(fs, "password"); err == nil {
+		t.Error("expected an error for the wrong key type")
+	}
+
+	// a changed character breaks the checksum
+	b := []byte(fs)
+	if b[20] == 'a' {
+		b[20] = 'b'
+	} else {
+		b[20] = 'a'
+	}
+	if EncryptedKeyStringType(string(b)) != InvalidEncryptedKey {
+		t.Error("expected an invalid key for a bad checksum")
+	}
+	if EncryptedKeyStringType(fa.SecString()) != InvalidEncryptedKey {
+		t.Error("a plain secret key is not an encrypted key")
+	}
+}
+
+func TestEncryptedSecStringLimits(t *testing.T) {
+	fa, _ := GetFactoidAddress("Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj")
+
+	fs, err := fa.EncryptedSecString("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// replace the scrypt parameters with logN=20, r=255, p=255 and fix the
+	// checksum. Deriving the key would need about 34GB of memory.
+	p := base58.Decode(fs)
+	p[4], p[5], p[6] = 20, 255, 255
+	h := sha256.Sum256(p[:len(p)-4])
+	h = sha256.Sum256(h[:])
+	copy(p[len(p)-4:], h[:4])
+	if _, err := GetEncryptedFactoidAddress(base58.Encode(p), "password"); err == nil {
+		t.Error("expected an error for expensive scrypt parameters")
+	}
+
+	r := KeyEncryptionR
+	KeyEncryptionR = 255
+	defer func() { KeyEncryptionR = r }()
+	if _, err := fa.EncryptedSecString("password"); err == nil {
+		t.Error("expected an error encrypting with expensive scrypt parameters")
+	}
+}
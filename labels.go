@@ -0,0 +1,202 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AddressLabel is a human readable description of a public Factoid address,
+// Entry Credit address, or identity key. The wallet keeps labels for its own
+// addresses and keys, and an address book of labels for external
+// counterparties.
+type AddressLabel struct {
+	Address string   `json:"address"`
+	Label   string   `json:"label"`
+	Tags    []string `json:"tags,omitempty"`
+	Notes   string   `json:"notes,omitempty"`
+}
+
+// NewAddressLabel returns an AddressLabel for a public Factoid address
+// (FA...), Entry Credit address (EC...), or identity key (idpub...).
+func NewAddressLabel(address, label string, tags []string, notes string) (*AddressLabel, error) {
+	if !IsLabelableAddress(address) {
+		return nil, fmt.Errorf("%s is not a public address or identity key", address)
+	}
+	if label == "" {
+		return nil, fmt.Errorf("label cannot be empty")
+	}
+
+	l := new(AddressLabel)
+	l.Address = address
+	l.Label = label
+	l.Tags = tags
+	l.Notes = notes
+	return l, nil
+}
+
+// IsLabelableAddress returns true for the public address and key types that
+// can be given an AddressLabel.
+func IsLabelableAddress(s string) bool {
+	switch AddressStringType(s) {
+	case FactoidPub, ECPub:
+		return true
+	}
+	return IdentityKeyStringType(s) == IDPub
+}
+
+// HasTag returns true if the label has the tag. Tags are not case sensitive.
+func (l *AddressLabel) HasTag(tag string) bool {
+	for _, t := range l.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *AddressLabel) MarshalBinary() ([]byte, error) {
+	return json.Marshal(l)
+}
+
+func (l *AddressLabel) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, l)
+}
+
+func (l *AddressLabel) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	return nil, l.UnmarshalBinary(data)
+}
+
+func (l *AddressLabel) String() string {
+	var s string
+	s += fmt.Sprintln("Address:", l.Address)
+	s += fmt.Sprintln("Label:", l.Label)
+	if len(l.Tags) > 0 {
+		s += fmt.Sprintln("Tags:", strings.Join(l.Tags, ", "))
+	}
+	if l.Notes != "" {
+		s += fmt.Sprintln("Notes:", l.Notes)
+	}
+	return s
+}
+
+// SetAddressLabel sets the label for an address or identity key held in the
+// wallet.
+func SetAddressLabel(l *AddressLabel) (*AddressLabel, error) {
+	return labelRequest("set-label", l)
+}
+
+// FetchAddressLabels returns the labels of the addresses and identity keys held
+// in the wallet.
+func FetchAddressLabels() ([]*AddressLabel, error) {
+	return labelsRequest("labels")
+}
+
+// RemoveAddressLabel removes the label for an address held in the wallet.
+func RemoveAddressLabel(address string) error {
+	return removeLabelRequest("remove-label", address)
+}
+
+// RestoreAddressLabels restores the labels and address book entries of a
+// wallet backup. Labels are only restored for addresses held in the wallet, so
+// the keys should be imported first. The labels that could not be restored are
+// returned.
+func RestoreAddressLabels(labels, contacts []*AddressLabel) ([]*AddressLabel, error) {
+	params := new(struct {
+		Labels   []*AddressLabel `json:"labels"`
+		Contacts []*AddressLabel `json:"contacts"`
+	})
+	params.Labels = labels
+	params.Contacts = contacts
+
+	req := NewJSON2Request("restore-labels", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(struct {
+		Skipped []*AddressLabel `json:"skipped"`
+	})
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return r.Skipped, nil
+}
+
+// AddContact adds an external address or identity key to the wallet address
+// book.
+func AddContact(l *AddressLabel) (*AddressLabel, error) {
+	return labelRequest("add-contact", l)
+}
+
+// FetchContacts returns all of the entries in the wallet address book.
+func FetchContacts() ([]*AddressLabel, error) {
+	return labelsRequest("contacts")
+}
+
+// RemoveContact removes an address from the wallet address book.
+func RemoveContact(address string) error {
+	return removeLabelRequest("remove-contact", address)
+}
+
+func labelRequest(method string, l *AddressLabel) (*AddressLabel, error) {
+	req := NewJSON2Request(method, APICounter(), l)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(AddressLabel)
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func labelsRequest(method string) ([]*AddressLabel, error) {
+	req := NewJSON2Request(method, APICounter(), nil)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(struct {
+		Labels []*AddressLabel `json:"labels"`
+	})
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return r.Labels, nil
+}
+
+func removeLabelRequest(method, address string) error {
+	params := new(struct {
+		Address string `json:"address"`
+	})
+	params.Address = address
+
+	req := NewJSON2Request(method, APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestNewAddressLabel(t *testing.T) {
+	for _, a := range []string{
+		"FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q",
+		"EC3MAHiZyfuEb5fZP2fSp2gXMv8WemhQEUFXyQ2f2HjSkYx7xY1S",
+	} {
+		l, err := NewAddressLabel(a, "payroll", []string{"Finance"}, "")
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !l.HasTag("finance") || l.HasTag("ops") {
+			t.Errorf("wrong tags %v", l.Tags)
+		}
+	}
+
+	if _, err := NewAddressLabel("Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj", "secret", nil, ""); err == nil {
+		t.Error("expected error for a secret address")
+	}
+	if _, err := NewAddressLabel("FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", "", nil, ""); err == nil {
+		t.Error("expected error for an empty label")
+	}
+}
+
+func TestFetchContacts(t *testing.T) {
+	simlatedWalletResponse := `{
+  "jsonrpc": "2.0",
+  "id": 0,
+  "result": {
+    "labels": [
+      {
+        "address": "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q",
+        "label": "Exchange deposit",
+        "tags": ["exchange"],
+        "notes": "only send FCT"
+      }
+    ]
+  }
+}`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, simlatedWalletResponse)
+	}))
+	defer ts.Close()
+
+	SetWalletServer(ts.URL[7:])
+
+	ls, err := FetchContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ls) != 1 {
+		t.Fatalf("expected 1 contact, got %d", len(ls))
+	}
+	if ls[0].Label != "Exchange deposit" || ls[0].Notes != "only send FCT" || !ls[0].HasTag("exchange") {
+		t.Errorf("unexpected contact %v", ls[0])
+	}
+}
+
+func TestRestoreAddressLabels(t *testing.T) {
+	simlatedWalletResponse := `{
+  "jsonrpc": "2.0",
+  "id": 0,
+  "result": {
+    "skipped": [
+      {
+        "address": "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q",
+        "label": "Old savings"
+      }
+    ]
+  }
+}`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, simlatedWalletResponse)
+	}))
+	defer ts.Close()
+
+	SetWalletServer(ts.URL[7:])
+
+	skipped, err := RestoreAddressLabels([]*AddressLabel{
+		{Address: "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", Label: "Old savings"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 || skipped[0].Label != "Old savings" {
+		t.Errorf("unexpected skipped labels %v", skipped)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	ed "github.com/FactomProject/ed25519"
+)
+
+// MessagePrefix separates signed messages from transactions, commits, and
+// identity entries signed with the same keys.
+const MessagePrefix = "Factom Signed Message:\n"
+
+// MessageSignature is the signature of a message by a Factoid address, an
+// Entry Credit address, or an Identity Key. The public key is included because
+// a Factoid address is only the hash of its public key.
+type MessageSignature struct {
+	PubKey    []byte `json:"pubkey"`
+	Signature []byte `json:"signature"`
+}
+
+// MessageHash returns the hash that is signed for msg:
+//
+//	sha256(MessagePrefix || sha256(msg))
+func MessageHash(msg []byte) []byte {
+	h := sha256.Sum256(msg)
+	d := sha256.Sum256(append([]byte(MessagePrefix), h[:]...))
+	return d[:]
+}
+
+// SignMessage signs msg with the Factoid address key.
+func (a *FactoidAddress) SignMessage(msg []byte) *MessageSignature {
+	s, _ := SignMessageWithSigner(a.Signer(), msg)
+	return s
+}
+
+// SignMessage signs msg with the Entry Credit address key.
+func (a *ECAddress) SignMessage(msg []byte) *MessageSignature {
+	s, _ := SignMessageWithSigner(a.Signer(), msg)
+	return s
+}
+
+// SignMessage signs msg with the Identity Key.
+func (k *IdentityKey) SignMessage(msg []byte) *MessageSignature {
+	s, _ := SignMessageWithSigner(k.Signer(), msg)
+	return s
+}
+
+// SignMessageWithSigner signs msg with the key held by signer.
+func SignMessageWithSigner(signer Signer, msg []byte) (*MessageSignature, error) {
+	sig, err := signWith(signer, MessageHash(msg))
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(MessageSignature)
+	s.PubKey = signer.PubBytes()
+	s.Signature = sig
+	return s, nil
+}
+
+// VerifyMessage checks that sig is a valid signature of msg by address, which
+// may be a public Factoid address, a public Entry Credit address, or a public
+// Identity Key.
+func VerifyMessage(address string, msg []byte, sig *MessageSignature) error {
+	if sig == nil || len(sig.PubKey) != ed.PublicKeySize || len(sig.Signature) != ed.SignatureSize {
+		return fmt.Errorf("invalid message signature")
+	}
+
+	s := &KeySigner{Pub: new([ed.PublicKeySize]byte)}
+	copy(s.Pub[:], sig.PubKey)
+
+	var signer string
+	switch {
+	case AddressStringType(address) == FactoidPub:
+		signer = SignerFactoidAddress(s)
+	case AddressStringType(address) == ECPub:
+		signer = SignerECAddress(s)
+	case IdentityKeyStringType(address) == IDPub:
+		signer = SignerIdentityKey(s)
+	default:
+		return fmt.Errorf("%s is not a public address or identity key", address)
+	}
+	if signer != address {
+		return fmt.Errorf("public key does not match %s", address)
+	}
+
+	sg := new([ed.SignatureSize]byte)
+	copy(sg[:], sig.Signature)
+	if !ed.Verify(s.Pub, MessageHash(msg), sg) {
+		return fmt.Errorf("invalid message signature")
+	}
+
+	return nil
+}
+
+// SignData signs data in the wallet with the key of signer, a public Factoid
+// address, Entry Credit address, or Identity Key held by the wallet.
+func SignData(signer string, data []byte) (*MessageSignature, error) {
+	params := new(struct {
+		Signer string `json:"signer"`
+		Data   []byte `json:"data"`
+	})
+	params.Signer = signer
+	params.Data = data
+
+	req := NewJSON2Request("sign-data", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	s := new(MessageSignature)
+	if err := json.Unmarshal(resp.JSONResult(), s); err != nil {
+		return nil, err
+	}
+	if err := VerifyMessage(signer, data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
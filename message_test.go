@@ -0,0 +1,60 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestMessageHash(t *testing.T) {
+	msg := []byte("I control this address")
+	h := sha256.Sum256(msg)
+	expected := sha256.Sum256(append([]byte("Factom Signed Message:\n"), h[:]...))
+	if !bytes.Equal(MessageHash(msg), expected[:]) {
+		t.Error("unexpected message hash")
+	}
+}
+
+func TestSignMessage(t *testing.T) {
+	fa, _ := GetFactoidAddress("Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj")
+	ec, _ := GetECAddress("Es2Rf7iM6PdsqfYCo3D1tnAR65SkLENyWJG1deUzpRMQmbh9F3eG")
+	id, _ := GetIdentityKey("idsec2rWrfNTD1x9HPPesA3fz8dmMNZdjmSBULHx8VTXE1J4D9icmAK")
+	msg := []byte("I control this address")
+
+	fs := fa.SignMessage(msg)
+	if err := VerifyMessage(fa.String(), msg, fs); err != nil {
+		t.Error(err)
+	}
+	es := ec.SignMessage(msg)
+	if err := VerifyMessage(ec.String(), msg, es); err != nil {
+		t.Error(err)
+	}
+	is := id.SignMessage(msg)
+	if err := VerifyMessage(id.String(), msg, is); err != nil {
+		t.Error(err)
+	}
+
+	if err := VerifyMessage(fa.String(), []byte("another message"), fs); err == nil {
+		t.Error("expected an error for a different message")
+	}
+	if err := VerifyMessage(SignerFactoidAddress(id.Signer()), msg, fs); err == nil {
+		t.Error("expected an error for a different address")
+	}
+	if err := VerifyMessage(fa.SecString(), msg, fs); err == nil {
+		t.Error("expected an error for a secret address")
+	}
+	if err := VerifyMessage(fa.String(), msg, nil); err == nil {
+		t.Error("expected an error for a missing signature")
+	}
+
+	bad := &MessageSignature{PubKey: fs.PubKey, Signature: make([]byte, 64)}
+	if err := VerifyMessage(fa.String(), msg, bad); err == nil {
+		t.Error("expected an error for an invalid signature")
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PendingEntry is an Entry that has been acknowledged by factomd but not yet
+// included in a saved Directory Block.
+type PendingEntry struct {
+	EntryHash string `json:"entryhash"`
+	ChainID   string `json:"chainid"`
+	Status    string `json:"status"`
+}
+
+func (e *PendingEntry) String() string {
+	var s string
+	s += fmt.Sprintln("EntryHash:", e.EntryHash)
+	s += fmt.Sprintln("ChainID:", e.ChainID)
+	s += fmt.Sprintln("Status:", e.Status)
+	return s
+}
+
+// PendingTransactionAddress is an input or output of a PendingTransaction.
+type PendingTransactionAddress struct {
+	Amount      uint64 `json:"amount"`
+	Address     string `json:"address"`
+	UserAddress string `json:"useraddress"`
+}
+
+// PendingTransaction is a Factoid Transaction that has been acknowledged by
+// factomd but not yet included in a saved Directory Block.
+type PendingTransaction struct {
+	TxID      string                       `json:"transactionid"`
+	DBHeight  uint32                       `json:"dbheight"`
+	Status    string                       `json:"status"`
+	Fees      uint64                       `json:"fees"`
+	Inputs    []*PendingTransactionAddress `json:"inputs"`
+	Outputs   []*PendingTransactionAddress `json:"outputs"`
+	ECOutputs []*PendingTransactionAddress `json:"ecoutputs"`
+}
+
+func (t *PendingTransaction) String() string {
+	var s string
+	s += fmt.Sprintln("TxID:", t.TxID)
+	s += fmt.Sprintln("Status:", t.Status)
+	s += fmt.Sprintln("DBHeight:", t.DBHeight)
+	for _, in := range t.Inputs {
+		s += fmt.Sprintln("Input:", in.UserAddress, FactoshiToFactoid(in.Amount))
+	}
+	for _, out := range t.Outputs {
+		s += fmt.Sprintln("Output:", out.UserAddress, FactoshiToFactoid(out.Amount))
+	}
+	for _, ec := range t.ECOutputs {
+		s += fmt.Sprintln("ECOutput:", ec.UserAddress, FactoshiToFactoid(ec.Amount))
+	}
+	s += fmt.Sprintln("Fees:", FactoshiToFactoid(t.Fees))
+	return s
+}
+
+// HasAddress returns true if the user facing address (FA... or EC...) is an
+// input or an output of the transaction.
+func (t *PendingTransaction) HasAddress(addr string) bool {
+	for _, in := range t.Inputs {
+		if in.UserAddress == addr {
+			return true
+		}
+	}
+	for _, out := range t.Outputs {
+		if out.UserAddress == addr {
+			return true
+		}
+	}
+	for _, ec := range t.ECOutputs {
+		if ec.UserAddress == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// ListPendingEntries returns all of the Entries that factomd has acknowledged
+// but not yet saved in a Directory Block.
+func ListPendingEntries() ([]*PendingEntry, error) {
+	req := NewJSON2Request("pending-entries", APICounter(), nil)
+	resp, err := factomdRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	es := make([]*PendingEntry, 0)
+	if err := json.Unmarshal(resp.JSONResult(), &es); err != nil {
+		return nil, err
+	}
+
+	return es, nil
+}
+
+// ListPendingEntriesChain returns the pending Entries for a single Chain.
+func ListPendingEntriesChain(chainid string) ([]*PendingEntry, error) {
+	es, err := ListPendingEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*PendingEntry, 0)
+	for _, e := range es {
+		if e.ChainID == chainid {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered, nil
+}
+
+// ListPendingTransactions returns all of the Factoid Transactions that factomd
+// has acknowledged but not yet saved in a Directory Block.
+func ListPendingTransactions() ([]*PendingTransaction, error) {
+	req := NewJSON2Request("pending-transactions", APICounter(), nil)
+	resp, err := factomdRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	txs := make([]*PendingTransaction, 0)
+	if err := json.Unmarshal(resp.JSONResult(), &txs); err != nil {
+		return nil, err
+	}
+
+	return txs, nil
+}
+
+// ListPendingTransactionsAddress returns the pending Factoid Transactions that
+// include a specific Factoid or Entry Credit address.
+func ListPendingTransactionsAddress(addr string) ([]*PendingTransaction, error) {
+	switch AddressStringType(addr) {
+	case FactoidPub, ECPub:
+	default:
+		return nil, fmt.Errorf("%s is not a public Factoid or Entry Credit address", addr)
+	}
+
+	txs, err := ListPendingTransactions()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*PendingTransaction, 0)
+	for _, tx := range txs {
+		if tx.HasAddress(addr) {
+			filtered = append(filtered, tx)
+		}
+	}
+
+	return filtered, nil
+}
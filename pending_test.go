@@ -0,0 +1,132 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestListPendingEntries(t *testing.T) {
+	simlatedFactomdResponse := `{
+  "jsonrpc": "2.0",
+  "id": 0,
+  "result": [
+    {
+      "EntryHash": "deb7b0ab1f6bd1c84bd6ab0ce97c5c0d01ffce40e3f7ad9b2db1c2e40dcbf14a",
+      "ChainID": "a9fc0b656430d8bf71d180760b0b352c08f45e55a8cf157383613ab5f5ddc3ab",
+      "Status": "AckStatusACK"
+    },
+    {
+      "EntryHash": "ca1c8d1b6cdea8b9c1d6d5e95e4bf50b3ec3c0b0e3e0b4ec0fe6c1a4e6f21f3b",
+      "ChainID": "cffce0f409ebba4ed236d49d89c70e4bd1f1367d86402a3363366683265a242d",
+      "Status": "AckStatusNotConfirmed"
+    }
+  ]
+}`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, simlatedFactomdResponse)
+	}))
+	defer ts.Close()
+
+	url := ts.URL[7:]
+	SetFactomdServer(url)
+
+	es, err := ListPendingEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(es) != 2 {
+		t.Fatalf("expected 2 pending entries, got %d", len(es))
+	}
+	if es[0].EntryHash != "deb7b0ab1f6bd1c84bd6ab0ce97c5c0d01ffce40e3f7ad9b2db1c2e40dcbf14a" || es[0].Status != "AckStatusACK" {
+		t.Errorf("unexpected pending entry %v", es[0])
+	}
+
+	es, err = ListPendingEntriesChain("cffce0f409ebba4ed236d49d89c70e4bd1f1367d86402a3363366683265a242d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(es) != 1 || es[0].Status != "AckStatusNotConfirmed" {
+		t.Errorf("unexpected pending entries for chain %v", es)
+	}
+}
+
+func TestListPendingTransactions(t *testing.T) {
+	simlatedFactomdResponse := `{
+  "jsonrpc": "2.0",
+  "id": 0,
+  "result": [
+    {
+      "TransactionID": "21fc64855771f2ee12da2a85b1aa0108007ee3e3b4a0e5d0da2fe54d2dfae17b",
+      "DBHeight": 1000,
+      "Status": "AckStatusACK",
+      "Inputs": [
+        {
+          "amount": 100000000,
+          "address": "646f3e8750c550e4582eca5047546ffef89c13a175985e320232bacac81cc428",
+          "useraddress": "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"
+        }
+      ],
+      "Outputs": [],
+      "ECOutputs": [
+        {
+          "amount": 99988000,
+          "address": "c23ae8eec2beb181a0da926bd2344e988149fbe839fbc7489f2096e7d6110243",
+          "useraddress": "EC3MAHiZyfuEb5fZP2fSp2gXMv8WemhQEUFXyQ2f2HjSkYx7xY1S"
+        }
+      ],
+      "Fees": 12000
+    }
+  ]
+}`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, simlatedFactomdResponse)
+	}))
+	defer ts.Close()
+
+	url := ts.URL[7:]
+	SetFactomdServer(url)
+
+	txs, err := ListPendingTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 1 {
+		t.Fatalf("expected 1 pending transaction, got %d", len(txs))
+	}
+	tx := txs[0]
+	if tx.TxID != "21fc64855771f2ee12da2a85b1aa0108007ee3e3b4a0e5d0da2fe54d2dfae17b" || tx.DBHeight != 1000 || tx.Fees != 12000 {
+		t.Errorf("unexpected pending transaction %v", tx)
+	}
+
+	txs, err = ListPendingTransactionsAddress("EC3MAHiZyfuEb5fZP2fSp2gXMv8WemhQEUFXyQ2f2HjSkYx7xY1S")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 1 {
+		t.Errorf("expected 1 pending transaction for the address, got %d", len(txs))
+	}
+
+	txs, err = ListPendingTransactionsAddress("FA3T1gTkuKGG2MWpAkskSoTnfjxZDKVaAYwziNTC1pAYH5B9A1rh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 0 {
+		t.Errorf("expected no pending transactions for the address, got %d", len(txs))
+	}
+
+	if _, err := ListPendingTransactionsAddress("Fs2TCa7Mo4XGy9FQSoZS8JPnDfv7SjwUSGqrjMWvc1RJ9sKbJeXA"); err == nil {
+		t.Error("expected an error for a private address")
+	}
+}
@@ -0,0 +1,416 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// SemVer is a parsed semantic version number.
+type SemVer struct {
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string
+}
+
+// ParseSemVer parses version strings of the forms "1", "1.2", "1.2.3", and
+// "1.2.3-pre" with an optional leading "v". Build metadata after a "+" is
+// ignored.
+func ParseSemVer(s string) (*SemVer, error) {
+	v := new(SemVer)
+
+	str := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.Index(str, "+"); i != -1 {
+		str = str[:i]
+	}
+	if i := strings.Index(str, "-"); i != -1 {
+		v.PreRelease = str[i+1:]
+		str = str[:i]
+	}
+
+	parts := strings.Split(str, ".")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("invalid version %q", s)
+	}
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+
+	return v, nil
+}
+
+// Compare returns -1, 0, or 1 if v is lower than, equal to, or higher than o.
+// A pre-release version is lower than the same version without a pre-release.
+// Pre-releases are compared by their dot separated identifiers, with runs of
+// digits compared numerically, so "rc2" is lower than "rc10".
+func (v *SemVer) Compare(o *SemVer) int {
+	a := []int{v.Major, v.Minor, v.Patch}
+	b := []int{o.Major, o.Minor, o.Patch}
+	for i := range a {
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+
+	switch {
+	case v.PreRelease == o.PreRelease:
+		return 0
+	case v.PreRelease == "":
+		return 1
+	case o.PreRelease == "":
+		return -1
+	default:
+		return comparePreRelease(v.PreRelease, o.PreRelease)
+	}
+}
+
+func comparePreRelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifier(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(as), len(bs))
+}
+
+// compareIdentifier compares two pre-release identifiers chunk by chunk, where
+// a chunk is a run of digits or of other characters. Digits sort before other
+// characters.
+func compareIdentifier(a, b string) int {
+	ac, bc := splitDigits(a), splitDigits(b)
+	for i := 0; i < len(ac) && i < len(bc); i++ {
+		an, aerr := strconv.ParseUint(ac[i], 10, 64)
+		bn, berr := strconv.ParseUint(bc[i], 10, 64)
+		switch {
+		case aerr == nil && berr == nil:
+			if an < bn {
+				return -1
+			} else if an > bn {
+				return 1
+			}
+		case aerr == nil:
+			return -1
+		case berr == nil:
+			return 1
+		case ac[i] < bc[i]:
+			return -1
+		case ac[i] > bc[i]:
+			return 1
+		}
+	}
+	return compareInts(len(ac), len(bc))
+}
+
+func splitDigits(s string) []string {
+	var chunks []string
+	start := 0
+	for i, r := range s {
+		if i > start && unicode.IsDigit(r) != unicode.IsDigit(rune(s[i-1])) {
+			chunks = append(chunks, s[start:i])
+			start = i
+		}
+	}
+	if start < len(s) {
+		chunks = append(chunks, s[start:])
+	}
+	return chunks
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (v *SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// VersionRange is a range of versions including Min and excluding Max.
+type VersionRange struct {
+	Min string
+	Max string
+}
+
+// Contains returns true if the version string s is within the range.
+func (r VersionRange) Contains(s string) (bool, error) {
+	v, err := ParseSemVer(s)
+	if err != nil {
+		return false, err
+	}
+	min, err := ParseSemVer(r.Min)
+	if err != nil {
+		return false, err
+	}
+	max, err := ParseSemVer(r.Max)
+	if err != nil {
+		return false, err
+	}
+
+	return v.Compare(min) >= 0 && v.Compare(max) < 0, nil
+}
+
+func (r VersionRange) String() string {
+	return fmt.Sprintf("[%s, %s)", r.Min, r.Max)
+}
+
+// Properties are the versions reported by factomd and factom-walletd. The *Err
+// fields are set if the corresponding version could not be retrieved.
+type Properties struct {
+	FactomdVersion       string `json:"factomdversion"`
+	FactomdVersionErr    string `json:"factomdversionerr"`
+	FactomdAPIVersion    string `json:"factomdapiversion"`
+	FactomdAPIVersionErr string `json:"factomdapiversionerr"`
+	WalletVersion        string `json:"walletversion"`
+	WalletVersionErr     string `json:"walletversionerr"`
+	WalletAPIVersion     string `json:"walletapiversion"`
+	WalletAPIVersionErr  string `json:"walletapiversionerr"`
+}
+
+func (p *Properties) String() string {
+	var s string
+	s += fmt.Sprintln("FactomdVersion:", p.FactomdVersion, p.FactomdVersionErr)
+	s += fmt.Sprintln("FactomdAPIVersion:", p.FactomdAPIVersion, p.FactomdAPIVersionErr)
+	s += fmt.Sprintln("WalletVersion:", p.WalletVersion, p.WalletVersionErr)
+	s += fmt.Sprintln("WalletAPIVersion:", p.WalletAPIVersion, p.WalletAPIVersionErr)
+	return s
+}
+
+// FactomdSemVer returns the parsed factomd version.
+func (p *Properties) FactomdSemVer() (*SemVer, error) {
+	return ParseSemVer(p.FactomdVersion)
+}
+
+// FactomdAPISemVer returns the parsed factomd API version.
+func (p *Properties) FactomdAPISemVer() (*SemVer, error) {
+	return ParseSemVer(p.FactomdAPIVersion)
+}
+
+// WalletSemVer returns the parsed factom-walletd version.
+func (p *Properties) WalletSemVer() (*SemVer, error) {
+	return ParseSemVer(p.WalletVersion)
+}
+
+// WalletAPISemVer returns the parsed factom-walletd API version.
+func (p *Properties) WalletAPISemVer() (*SemVer, error) {
+	return ParseSemVer(p.WalletAPIVersion)
+}
+
+// GetAllProperties returns the versions of factomd and factom-walletd.
+func GetAllProperties() *Properties {
+	props := getFactomdProperties(factomdRequest)
+	wprops := getWalletProperties(walletRequest)
+
+	props.WalletVersion = wprops.WalletVersion
+	props.WalletVersionErr = wprops.WalletVersionErr
+	props.WalletAPIVersion = wprops.WalletAPIVersion
+	props.WalletAPIVersionErr = wprops.WalletAPIVersionErr
+
+	return props
+}
+
+// getFactomdProperties fills in the factomd fields of a Properties struct.
+func getFactomdProperties(send func(*JSON2Request) (*JSON2Response, error)) *Properties {
+	props := new(Properties)
+	req := NewJSON2Request("properties", APICounter(), nil)
+
+	resp, err := send(req)
+	if err != nil {
+		props.FactomdVersionErr = err.Error()
+	} else if resp.Error != nil {
+		props.FactomdVersionErr = resp.Error.Error()
+	} else if jerr := json.Unmarshal(resp.JSONResult(), props); jerr != nil {
+		props.FactomdVersionErr = jerr.Error()
+	}
+
+	return props
+}
+
+// getWalletProperties fills in the factom-walletd fields of a Properties
+// struct.
+func getWalletProperties(send func(*JSON2Request) (*JSON2Response, error)) *Properties {
+	props := new(Properties)
+	req := NewJSON2Request("properties", APICounter(), nil)
+
+	resp, err := send(req)
+	if err != nil {
+		props.WalletVersionErr = err.Error()
+	} else if resp.Error != nil {
+		props.WalletVersionErr = resp.Error.Error()
+	} else if jerr := json.Unmarshal(resp.JSONResult(), props); jerr != nil {
+		props.WalletVersionErr = jerr.Error()
+	}
+
+	return props
+}
+
+// CompatibilityMode sets what happens when the API version of factomd or
+// factom-walletd is outside of the supported range.
+type CompatibilityMode int
+
+const (
+	// CompatibilityIgnore does not check the API versions. This is the
+	// default.
+	CompatibilityIgnore CompatibilityMode = iota
+	// CompatibilityWarn reports a mismatch to the compatibility warning
+	// handler on first contact.
+	CompatibilityWarn
+	// CompatibilityFail makes every request fail with the compatibility error.
+	CompatibilityFail
+)
+
+var (
+	// SupportedFactomdAPI is the range of factomd API versions this client
+	// is known to work with.
+	SupportedFactomdAPI = VersionRange{Min: "2.0", Max: "3.0"}
+	// SupportedWalletAPI is the range of factom-walletd API versions this
+	// client is known to work with.
+	SupportedWalletAPI = VersionRange{Min: "2.0", Max: "3.0"}
+
+	compatibility = &compatibilityConfig{
+		mode: CompatibilityIgnore,
+		warn: func(err error) { log.Println("factom: Warning:", err) },
+	}
+
+	factomdCompat = new(compatibilityCheck)
+	walletCompat  = new(compatibilityCheck)
+)
+
+// compatibilityConfig holds the compatibility mode and warning handler.
+type compatibilityConfig struct {
+	sync.RWMutex
+	mode CompatibilityMode
+	warn func(error)
+}
+
+func (c *compatibilityConfig) get() (CompatibilityMode, func(error)) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.mode, c.warn
+}
+
+// SetCompatibilityMode sets the API version check run on first contact with
+// factomd and factom-walletd. Changing the mode causes the check to run again.
+func SetCompatibilityMode(m CompatibilityMode) {
+	compatibility.Lock()
+	compatibility.mode = m
+	compatibility.Unlock()
+
+	factomdCompat.reset()
+	walletCompat.reset()
+}
+
+// GetCompatibilityMode returns the current compatibility mode.
+func GetCompatibilityMode() CompatibilityMode {
+	m, _ := compatibility.get()
+	return m
+}
+
+// SetCompatibilityWarningHandler sets the function called with the
+// compatibility error in CompatibilityWarn mode. By default the warning is
+// written with the standard logger. A nil handler discards warnings.
+func SetCompatibilityWarningHandler(f func(error)) {
+	compatibility.Lock()
+	defer compatibility.Unlock()
+	compatibility.warn = f
+}
+
+// CheckFactomdCompatibility returns an error if the factomd API version is
+// outside of SupportedFactomdAPI.
+func CheckFactomdCompatibility() error {
+	props := getFactomdProperties(sendFactomdRequest)
+	if props.FactomdVersionErr != "" {
+		return fmt.Errorf("factomd API version: %s", props.FactomdVersionErr)
+	}
+	return checkVersion("factomd", props.FactomdAPIVersion, SupportedFactomdAPI)
+}
+
+// CheckWalletCompatibility returns an error if the factom-walletd API version
+// is outside of SupportedWalletAPI.
+func CheckWalletCompatibility() error {
+	props := getWalletProperties(sendWalletRequest)
+	if props.WalletVersionErr != "" {
+		return fmt.Errorf("factom-walletd API version: %s", props.WalletVersionErr)
+	}
+	return checkVersion("factom-walletd", props.WalletAPIVersion, SupportedWalletAPI)
+}
+
+func checkVersion(name, version string, r VersionRange) error {
+	ok, err := r.Contains(version)
+	if err != nil {
+		return fmt.Errorf("%s API version: %s", name, err)
+	}
+	if !ok {
+		return fmt.Errorf("%s API version %s is not in the supported range %s", name, version, r)
+	}
+	return nil
+}
+
+// compatibilityCheck remembers the server that last passed the compatibility
+// check.
+type compatibilityCheck struct {
+	sync.Mutex
+	server string
+}
+
+func (c *compatibilityCheck) reset() {
+	c.Lock()
+	defer c.Unlock()
+	c.server = ""
+}
+
+// run calls check on first contact with a server and handles the result
+// according to the compatibility mode. A failed check is retried on the next
+// request in CompatibilityFail mode, and reported only once in
+// CompatibilityWarn mode.
+func (c *compatibilityCheck) run(server string, check func() error) error {
+	mode, warn := compatibility.get()
+	if mode == CompatibilityIgnore {
+		return nil
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if c.server == server {
+		return nil
+	}
+
+	err := check()
+	if err != nil && mode == CompatibilityFail {
+		return err
+	}
+	if err != nil && warn != nil {
+		warn(err)
+	}
+	c.server = server
+
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestMain(m *testing.M) {
+	// the mock servers in the tests do not implement the properties call, so
+	// the default mode must not check the API versions
+	if GetCompatibilityMode() != CompatibilityIgnore {
+		fmt.Println("expected CompatibilityIgnore to be the default mode")
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestParseSemVer(t *testing.T) {
+	for _, c := range []struct {
+		A, B string
+		Cmp  int
+	}{
+		{"6.1.0", "v6.1.0", 0},
+		{"2.0", "2.0.0", 0},
+		{"2.0.1", "2.0", 1},
+		{"2.0.0-rc1", "2.0.0", -1},
+		{"2.0.0-rc1", "2.0.0-rc2", -1},
+		{"2.0.0-rc2", "2.0.0-rc10", -1},
+		{"2.0.0-beta.2", "2.0.0-beta.11", -1},
+		{"2.0.0-1", "2.0.0-alpha", -1},
+		{"2.0.0-alpha", "2.0.0-alpha.1", -1},
+		{"10.0", "9.9.9", 1},
+		{"1.2.3+build5", "1.2.3", 0},
+	} {
+		a, err := ParseSemVer(c.A)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseSemVer(c.B)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cmp := a.Compare(b); cmp != c.Cmp {
+			t.Errorf("%s compared to %s: expected %d, got %d", c.A, c.B, c.Cmp, cmp)
+		}
+	}
+
+	for _, s := range []string{"", "a.b", "1.2.3.4", "1.-2"} {
+		if _, err := ParseSemVer(s); err == nil {
+			t.Errorf("expected error parsing %q", s)
+		}
+	}
+
+	r := VersionRange{Min: "2.0", Max: "3.0"}
+	for v, want := range map[string]bool{
+		"1.9":   false,
+		"2.0":   true,
+		"2.9.9": true,
+		"3.0":   false,
+	} {
+		if ok, err := r.Contains(v); err != nil {
+			t.Error(err)
+		} else if ok != want {
+			t.Errorf("%s in %s: expected %t, got %t", v, r, want, ok)
+		}
+	}
+}
+
+func TestGetAllProperties(t *testing.T) {
+	apiversion := "2.0"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		req, _ := ParseJSON2Request(string(body))
+		w.Header().Set("Content-Type", "application/json")
+
+		switch req.Method {
+		case "properties":
+			fmt.Fprintf(w, `{"jsonrpc": "2.0", "id": 0, "result": {"factomdversion": "6.1.0", "factomdapiversion": %q, "walletversion": "2.2.14", "walletapiversion": "2.0"}}`, apiversion)
+		case "heights":
+			fmt.Fprintln(w, `{"jsonrpc": "2.0", "id": 0, "result": {"directoryblockheight": 72498, "leaderheight": 72498, "entryblockheight": 72498, "entryheight": 72498}}`)
+		default:
+			t.Errorf("unexpected api call %s", req.Method)
+		}
+	}))
+	defer ts.Close()
+
+	SetFactomdServer(ts.URL[7:])
+	SetWalletServer(ts.URL[7:])
+	defer SetCompatibilityMode(CompatibilityIgnore)
+
+	props := GetAllProperties()
+	if props.FactomdVersion != "6.1.0" || props.WalletVersion != "2.2.14" {
+		t.Errorf("unexpected properties %v", props)
+	}
+	v, err := props.FactomdSemVer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Major != 6 || v.Minor != 1 {
+		t.Errorf("unexpected factomd version %s", v)
+	}
+
+	SetCompatibilityMode(CompatibilityFail)
+	if _, err := GetHeights(); err != nil {
+		t.Error(err)
+	}
+
+	// an unsupported API version makes every request fail
+	apiversion = "3.1"
+	SetCompatibilityMode(CompatibilityFail)
+	if _, err := GetHeights(); err == nil {
+		t.Error("expected compatibility error")
+	}
+
+	// warnings do not stop the request
+	var warnings []error
+	SetCompatibilityWarningHandler(func(err error) { warnings = append(warnings, err) })
+	SetCompatibilityMode(CompatibilityWarn)
+	if _, err := GetHeights(); err != nil {
+		t.Error(err)
+	}
+	if _, err := GetHeights(); err != nil {
+		t.Error(err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("expected 1 warning, got %d", len(warnings))
+	}
+}
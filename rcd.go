@@ -5,6 +5,10 @@
 package factom
 
 import (
+	"bytes"
+	"fmt"
+
+	"github.com/FactomProject/btcutil/base58"
 	ed "github.com/FactomProject/ed25519"
 )
 
@@ -35,3 +39,226 @@ func (r *RCD1) Hash() []byte {
 func (r *RCD1) PubBytes() []byte {
 	return r.Pub[:]
 }
+
+// RCD2 is an m of n multisignature RCD. The members of the RCD are the RCD
+// Hashes of n RCD1s, and a transaction input using the RCD2 is valid when it
+// is signed by at least M of the members.
+type RCD2 struct {
+	M       int
+	Members [][]byte
+}
+
+// NewRCD2 creates an RCD2 requiring m signatures from the public Factoid
+// addresses (FA...) in members.
+func NewRCD2(m int, members ...string) (*RCD2, error) {
+	r := new(RCD2)
+	r.M = m
+	for _, s := range members {
+		if AddressStringType(s) != FactoidPub {
+			return nil, fmt.Errorf("%s is not a public Factoid address", s)
+		}
+		r.Members = append(r.Members, base58.Decode(s)[PrefixLength:BodyLength])
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RCD2) validate() error {
+	if len(r.Members) == 0 {
+		return fmt.Errorf("multisig RCD must have at least 1 member")
+	}
+	if r.M < 1 || r.M > len(r.Members) {
+		return fmt.Errorf(
+			"multisig RCD requires between 1 and %d signatures, not %d",
+			len(r.Members), r.M)
+	}
+	for i, a := range r.Members {
+		if len(a) != 32 {
+			return fmt.Errorf("multisig RCD member must be 32 bytes")
+		}
+		for _, b := range r.Members[:i] {
+			if bytes.Equal(a, b) {
+				return fmt.Errorf("duplicate multisig RCD member %s", fctAddressString(a))
+			}
+		}
+	}
+	return nil
+}
+
+func (r *RCD2) Type() uint8 {
+	return byte(2)
+}
+
+func (r *RCD2) Hash() []byte {
+	p, _ := r.MarshalBinary()
+	return shad(p)
+}
+
+// String returns the public Factoid address (FA...) of the RCD2.
+func (r *RCD2) String() string {
+	return fctAddressString(r.Hash())
+}
+
+// MemberStrings returns the public Factoid addresses (FA...) of the members.
+func (r *RCD2) MemberStrings() []string {
+	s := make([]string, len(r.Members))
+	for i, a := range r.Members {
+		s[i] = fctAddressString(a)
+	}
+	return s
+}
+
+// MemberIndex returns the position of the RCD1 public key among the members,
+// or -1 if it is not a member.
+func (r *RCD2) MemberIndex(pub []byte) int {
+	if len(pub) != ed.PublicKeySize {
+		return -1
+	}
+	r1 := NewRCD1()
+	copy(r1.Pub[:], pub)
+	h := r1.Hash()
+
+	for i, a := range r.Members {
+		if bytes.Equal(a, h) {
+			return i
+		}
+	}
+	return -1
+}
+
+// MarshalBinary encodes the RCD2 as the type byte, the varint number of
+// required signatures, the varint number of members, and the member RCD
+// Hashes.
+func (r *RCD2) MarshalBinary() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(r.Type())
+	buf.Write(encodeVarInt(uint64(r.M)))
+	buf.Write(encodeVarInt(uint64(len(r.Members))))
+	for _, a := range r.Members {
+		buf.Write(a)
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *RCD2) UnmarshalBinary(data []byte) error {
+	_, err := r.UnmarshalBinaryData(data)
+	return err
+}
+
+func (r *RCD2) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	if len(data) < 1 || data[0] != r.Type() {
+		return nil, fmt.Errorf("not a multisig RCD")
+	}
+
+	m, data, err := decodeVarInt(data[1:])
+	if err != nil {
+		return nil, err
+	}
+	n, data, err := decodeVarInt(data)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) < n*32 {
+		return nil, fmt.Errorf("multisig RCD is too short for %d members", n)
+	}
+
+	r.M = int(m)
+	r.Members = make([][]byte, n)
+	for i := range r.Members {
+		r.Members[i] = make([]byte, 32)
+		copy(r.Members[i], data[:32])
+		data = data[32:]
+	}
+
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// MultisigSignature is the signature of one member of an RCD2.
+type MultisigSignature struct {
+	Pub []byte `json:"pub"`
+	Sig []byte `json:"sig"`
+}
+
+// SignMultisig signs data with the Factoid address a for use in an RCD2.
+func SignMultisig(a *FactoidAddress, data []byte) *MultisigSignature {
+	s := new(MultisigSignature)
+	s.Pub = a.PubBytes()
+	s.Sig = ed.Sign(a.SecFixed(), data)[:]
+	return s
+}
+
+// SignMultisigWithSigner signs data with the key held by signer for use in an
+// RCD2.
+func SignMultisigWithSigner(signer Signer, data []byte) (*MultisigSignature, error) {
+	sig, err := signWith(signer, data)
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(MultisigSignature)
+	s.Pub = signer.PubBytes()
+	s.Sig = sig
+	return s, nil
+}
+
+// Verify checks that sigs contains at least M valid signatures of data from
+// distinct members of the RCD2.
+func (r *RCD2) Verify(data []byte, sigs []*MultisigSignature) error {
+	signed := make(map[int]bool)
+	for _, s := range sigs {
+		i, err := r.VerifySignature(data, s)
+		if err != nil {
+			return err
+		}
+		signed[i] = true
+	}
+	if len(signed) < r.M {
+		return fmt.Errorf(
+			"multisig RCD requires %d signatures, only %d present",
+			r.M, len(signed))
+	}
+	return nil
+}
+
+// VerifySignature checks a single member signature of data and returns the
+// position of the signer among the members.
+func (r *RCD2) VerifySignature(data []byte, s *MultisigSignature) (int, error) {
+	i := r.MemberIndex(s.Pub)
+	if i == -1 {
+		return -1, fmt.Errorf("signing key is not a member of %s", r)
+	}
+	if len(s.Sig) != ed.SignatureSize {
+		return -1, fmt.Errorf("invalid signature length %d", len(s.Sig))
+	}
+
+	pub := new([ed.PublicKeySize]byte)
+	copy(pub[:], s.Pub)
+	sig := new([ed.SignatureSize]byte)
+	copy(sig[:], s.Sig)
+	if !ed.Verify(pub, data, sig) {
+		return -1, fmt.Errorf("invalid signature from %s", fctAddressString(r.Members[i]))
+	}
+	return i, nil
+}
+
+// fctAddressString returns the public Factoid address (FA...) for an RCD Hash.
+func fctAddressString(rcdhash []byte) string {
+	buf := new(bytes.Buffer)
+
+	// FC address prefix
+	buf.Write(fcPubPrefix)
+
+	// RCD Hash
+	buf.Write(rcdhash)
+
+	// Checksum
+	check := shad(buf.Bytes())[:ChecksumLength]
+	buf.Write(check)
+
+	return base58.Encode(buf.Bytes())
+}
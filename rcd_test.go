@@ -0,0 +1,136 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestRCD2(t *testing.T) {
+	keys := make([]*FactoidAddress, 4)
+	for i := range keys {
+		k, err := MakeFactoidAddress(bytes.Repeat([]byte{byte(i + 1)}, 32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = k
+	}
+
+	r, err := NewRCD2(2, keys[0].String(), keys[1].String(), keys[2].String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if AddressStringType(r.String()) != FactoidPub {
+		t.Errorf("invalid multisig address %s", r)
+	}
+
+	// the address survives a round trip through the binary encoding
+	p, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2 := new(RCD2)
+	if err := r2.UnmarshalBinary(p); err != nil {
+		t.Fatal(err)
+	}
+	if r2.String() != r.String() || r2.M != 2 || len(r2.Members) != 3 {
+		t.Errorf("multisig RCD did not unmarshal correctly: %s", r2)
+	}
+	if r2.MemberStrings()[1] != keys[1].String() {
+		t.Errorf("wrong member %s", r2.MemberStrings()[1])
+	}
+
+	data := []byte("transaction data")
+	s0 := SignMultisig(keys[0], data)
+	s2 := SignMultisig(keys[2], data)
+	s3 := SignMultisig(keys[3], data)
+
+	if err := r.Verify(data, []*MultisigSignature{s0, s2}); err != nil {
+		t.Error(err)
+	}
+	if err := r.Verify(data, []*MultisigSignature{s0}); err == nil {
+		t.Error("expected error for too few signatures")
+	}
+	if err := r.Verify(data, []*MultisigSignature{s0, s0}); err == nil {
+		t.Error("expected error for duplicate signatures")
+	}
+	if err := r.Verify(data, []*MultisigSignature{s0, s3}); err == nil {
+		t.Error("expected error for a non member signature")
+	}
+	if err := r.Verify([]byte("other data"), []*MultisigSignature{s0, s2}); err == nil {
+		t.Error("expected error for signatures of other data")
+	}
+
+	if _, err := NewRCD2(4, keys[0].String(), keys[1].String(), keys[2].String()); err == nil {
+		t.Error("expected error for m > n")
+	}
+	if _, err := NewRCD2(1, keys[0].String(), keys[0].String()); err == nil {
+		t.Error("expected error for duplicate members")
+	}
+}
+
+func TestFetchMultisigAddresses(t *testing.T) {
+	keys := make([]string, 2)
+	for i := range keys {
+		k, err := MakeFactoidAddress(bytes.Repeat([]byte{byte(i + 1)}, 32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = k.String()
+	}
+	r, err := NewRCD2(1, keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	multisig := fmt.Sprintf(`{
+        "public": "%s",
+        "secret": "",
+        "required": 1,
+        "members": ["%s", "%s"]
+      }`, r.String(), keys[0], keys[1])
+	responses := map[string]string{
+		"multisig-addresses": multisig,
+		// all-addresses lists the multisig addresses next to the addresses
+		// with Factoid keys
+		"all-addresses": `{
+        "public": "FA3T1gTkuKGG2MWpAkskSoTnfjxZDKVaAYwziNTC1pAYH5B9A1rh",
+        "secret": "Fs2TCa7Mo4XGy9FQSoZS8JPnDfv7SjwUSGqrjMWvc1RJ9sKbJeXA"
+      }, ` + multisig,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := new(JSON2Request)
+		json.NewDecoder(r.Body).Decode(req)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc": "2.0", "id": 0, "result": {"addresses": [%s]}}`, responses[req.Method])
+	}))
+	defer ts.Close()
+
+	SetWalletServer(ts.URL[7:])
+
+	ms, err := FetchMultisigAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 1 || ms[0].String() != r.String() || ms[0].M != 1 {
+		t.Errorf("unexpected multisig addresses %v", ms)
+	}
+
+	fs, es, err := FetchAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fs) != 1 || len(es) != 0 {
+		t.Errorf("expected only the Factoid address with a key, got %d and %d", len(fs), len(es))
+	}
+}
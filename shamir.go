@@ -0,0 +1,244 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/btcutil/base58"
+)
+
+// A SecretShare is one of n shares of a secret split with Shamir's secret
+// sharing scheme over GF(256). Any m shares of the same set recover the
+// secret, and fewer than m shares reveal nothing about it.
+//
+// The string encoding of a share is base58 of
+//
+//	version (1 byte, 0x01) | set id (4 bytes) | threshold m (1 byte) |
+//	share index x (1 byte) | share data | checksum (4 bytes)
+//
+// where the share data is as long as the secret and the checksum is the
+// first 4 bytes of sha256d of the preceding bytes.
+type SecretShare struct {
+	ID        uint32
+	Threshold byte
+	Index     byte
+	Data      []byte
+}
+
+const secretShareVersion = 0x01
+
+// secretShareHeader is the size of the version, set id, threshold, and index
+const secretShareHeader = 7
+
+// SplitSecret splits secret into n shares, any m of which recover it.
+func SplitSecret(secret []byte, m, n int) ([]*SecretShare, error) {
+	if m < 2 || m > n || n > 255 {
+		return nil, fmt.Errorf("invalid share threshold %d of %d", m, n)
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("secret is empty")
+	}
+
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	shares := make([]*SecretShare, n)
+	for i := range shares {
+		s := new(SecretShare)
+		s.ID = binary.BigEndian.Uint32(id)
+		s.Threshold = byte(m)
+		s.Index = byte(i + 1)
+		s.Data = make([]byte, len(secret))
+		shares[i] = s
+	}
+
+	// each byte of the secret is the constant term of a random polynomial of
+	// degree m-1 that is evaluated at the share index
+	coef := make([]byte, m)
+	for j, b := range secret {
+		coef[0] = b
+		if _, err := rand.Read(coef[1:]); err != nil {
+			return nil, err
+		}
+		for _, s := range shares {
+			s.Data[j] = gfPolyEval(coef, s.Index)
+		}
+	}
+
+	return shares, nil
+}
+
+// CombineSecretShares recovers the secret from at least Threshold shares of
+// the same set.
+func CombineSecretShares(shares ...*SecretShare) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no shares provided")
+	}
+
+	first := shares[0]
+	seen := make(map[byte]bool)
+	for _, s := range shares {
+		if s.ID != first.ID || s.Threshold != first.Threshold || len(s.Data) != len(first.Data) {
+			return nil, fmt.Errorf("shares are not from the same set")
+		}
+		if s.Index == 0 {
+			return nil, fmt.Errorf("invalid share index 0")
+		}
+		if seen[s.Index] {
+			return nil, fmt.Errorf("duplicate share %d", s.Index)
+		}
+		seen[s.Index] = true
+	}
+	if len(shares) < int(first.Threshold) {
+		return nil, fmt.Errorf("%d shares are required, found %d", first.Threshold, len(shares))
+	}
+	shares = shares[:first.Threshold]
+
+	// Lagrange interpolation at x = 0
+	secret := make([]byte, len(first.Data))
+	for i, si := range shares {
+		basis := byte(1)
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			basis = gfMul(basis, gfDiv(sj.Index, sj.Index^si.Index))
+		}
+		for k := range secret {
+			secret[k] ^= gfMul(si.Data[k], basis)
+		}
+	}
+
+	return secret, nil
+}
+
+// ParseSecretShare decodes the string encoding of a SecretShare.
+func ParseSecretShare(s string) (*SecretShare, error) {
+	p := base58.Decode(s)
+	if len(p) < secretShareHeader+1+ChecksumLength {
+		return nil, fmt.Errorf("invalid share")
+	}
+
+	body := p[:len(p)-ChecksumLength]
+	check := p[len(p)-ChecksumLength:]
+	if !bytes.Equal(shad(body)[:ChecksumLength], check) {
+		return nil, fmt.Errorf("invalid share checksum")
+	}
+	if body[0] != secretShareVersion {
+		return nil, fmt.Errorf("unsupported share version %d", body[0])
+	}
+
+	share := new(SecretShare)
+	share.ID = binary.BigEndian.Uint32(body[1:5])
+	share.Threshold = body[5]
+	share.Index = body[6]
+	share.Data = body[secretShareHeader:]
+	return share, nil
+}
+
+func (s *SecretShare) String() string {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(secretShareVersion)
+	binary.Write(buf, binary.BigEndian, s.ID)
+	buf.WriteByte(s.Threshold)
+	buf.WriteByte(s.Index)
+	buf.Write(s.Data)
+
+	check := shad(buf.Bytes())[:ChecksumLength]
+	buf.Write(check)
+
+	return base58.Encode(buf.Bytes())
+}
+
+// SplitMnemonic splits a 12 word mnemonic seed into n share strings, any m of
+// which recover it with CombineMnemonicShares. A BIP39 passphrase is not part
+// of the shares and must be kept separately.
+func SplitMnemonic(mnemonic string, m, n int) ([]string, error) {
+	mnemonic, err := ParseAndValidateMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := SplitSecret([]byte(mnemonic), m, n)
+	if err != nil {
+		return nil, err
+	}
+
+	ss := make([]string, len(shares))
+	for i, s := range shares {
+		ss[i] = s.String()
+	}
+	return ss, nil
+}
+
+// CombineMnemonicShares recovers a mnemonic seed from share strings created
+// by SplitMnemonic.
+func CombineMnemonicShares(shares ...string) (string, error) {
+	ss := make([]*SecretShare, len(shares))
+	for i, s := range shares {
+		share, err := ParseSecretShare(s)
+		if err != nil {
+			return "", err
+		}
+		ss[i] = share
+	}
+
+	secret, err := CombineSecretShares(ss...)
+	if err != nil {
+		return "", err
+	}
+
+	return ParseAndValidateMnemonic(string(secret))
+}
+
+// GF(256) arithmetic with the AES polynomial x^8 + x^4 + x^3 + x + 1
+var gfExp, gfLog = gfTables()
+
+func gfTables() (exp [510]byte, log [256]byte) {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i] = x
+		exp[i+255] = x
+		log[x] = byte(i)
+
+		// multiply by the generator 3
+		hi := x & 0x80
+		x2 := x << 1
+		if hi != 0 {
+			x2 ^= 0x1b
+		}
+		x ^= x2
+	}
+	return
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// gfPolyEval evaluates the polynomial with coefficients coef at x.
+func gfPolyEval(coef []byte, x byte) byte {
+	var y byte
+	for i := len(coef) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ coef[i]
+	}
+	return y
+}
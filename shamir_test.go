@@ -0,0 +1,103 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestSplitSecret(t *testing.T) {
+	secret := []byte("yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow")
+
+	shares, err := SplitSecret(secret, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("expected 5 shares, found %d", len(shares))
+	}
+
+	// every combination of 3 shares recovers the secret
+	for i := 0; i < 5; i++ {
+		for j := i + 1; j < 5; j++ {
+			for k := j + 1; k < 5; k++ {
+				s, err := CombineSecretShares(shares[i], shares[j], shares[k])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(s, secret) {
+					t.Errorf("shares %d %d %d recovered %q", i, j, k, s)
+				}
+			}
+		}
+	}
+
+	if _, err := CombineSecretShares(shares[0], shares[1]); err == nil {
+		t.Error("expected an error for too few shares")
+	}
+	if _, err := CombineSecretShares(shares[0], shares[1], shares[1]); err == nil {
+		t.Error("expected an error for duplicate shares")
+	}
+
+	other, err := SplitSecret(secret, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CombineSecretShares(shares[0], shares[1], other[2]); err == nil {
+		t.Error("expected an error for shares from different sets")
+	}
+
+	for _, mn := range [][2]int{{1, 3}, {4, 3}, {2, 256}} {
+		if _, err := SplitSecret(secret, mn[0], mn[1]); err == nil {
+			t.Errorf("expected an error for %d of %d shares", mn[0], mn[1])
+		}
+	}
+}
+
+func TestParseSecretShare(t *testing.T) {
+	shares, err := SplitSecret([]byte("secret"), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range shares {
+		p, err := ParseSecretShare(s.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.ID != s.ID || p.Threshold != s.Threshold || p.Index != s.Index || !bytes.Equal(p.Data, s.Data) {
+			t.Errorf("share %d did not round trip", s.Index)
+		}
+	}
+
+	b := []byte(shares[0].String())
+	if b[10] == 'a' {
+		b[10] = 'b'
+	} else {
+		b[10] = 'a'
+	}
+	if _, err := ParseSecretShare(string(b)); err == nil {
+		t.Error("expected an error for a bad checksum")
+	}
+}
+
+func TestSplitMnemonic(t *testing.T) {
+	m := "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow"
+
+	shares, err := SplitMnemonic(m, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := CombineMnemonicShares(shares[2], shares[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != m {
+		t.Errorf("recovered %q", r)
+	}
+}
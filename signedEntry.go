@@ -0,0 +1,236 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"github.com/FactomProject/btcutil/base58"
+	ed "github.com/FactomProject/ed25519"
+)
+
+// An identity signed entry is any entry signed with an Identity Key. The
+// first five ExtIDs are reserved, similar to the attribute format:
+//
+//	"SignedEntry" | timestamp (8 bytes, unix seconds) | signature |
+//	signer key (idpub) | signer chain ID
+//
+// and are followed by the ExtIDs of the entry. The signed message is
+//
+//	"SignedEntry" + ChainID + SignerChainID + timestamp +
+//	sha256(ExtID) for each entry ExtID + sha256(Content)
+//
+// so the signature covers the chain, the signer, and the whole entry.
+
+// SignedEntryExtIDs is the number of ExtIDs reserved in an identity signed
+// entry.
+const SignedEntryExtIDs = 5
+
+// SignedEntryWindow is how far the signed timestamp of an identity signed
+// entry may be from the time of the block it was published in. Entries
+// outside of the window are skipped by the VerifiedChainReader, so that an old
+// signed entry cannot be replayed.
+const SignedEntryWindow = 12 * time.Hour
+
+// NewSignedEntry returns a copy of e signed with the Identity Key of the signer
+// identity. Publish it to the blockchain using the usual
+// factom.CommitEntry(...) and factom.RevealEntry(...) calls.
+func NewSignedEntry(e *Entry, signerKey *IdentityKey, signerChainID string) *Entry {
+	s, _ := NewSignedEntryWithSigner(e, signerKey.Signer(), signerChainID)
+	return s
+}
+
+// NewSignedEntryWithSigner returns a copy of e signed like NewSignedEntry, by
+// the Identity Key held by signer.
+func NewSignedEntryWithSigner(e *Entry, signer Signer, signerChainID string) (*Entry, error) {
+	ts := make([]byte, 8)
+	binary.BigEndian.PutUint64(ts, uint64(time.Now().Unix()))
+
+	signature, err := signWith(signer, signedEntryMessage(e.ChainID, signerChainID, ts, e.ExtIDs, e.Content))
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(Entry)
+	s.ChainID = e.ChainID
+	s.ExtIDs = [][]byte{
+		[]byte("SignedEntry"),
+		ts,
+		signature,
+		[]byte(SignerIdentityKey(signer)),
+		[]byte(signerChainID),
+	}
+	s.ExtIDs = append(s.ExtIDs, e.ExtIDs...)
+	s.Content = e.Content
+	return s, nil
+}
+
+func signedEntryMessage(chainID, signerChainID string, ts []byte, extIDs [][]byte, content []byte) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString("SignedEntry" + chainID + signerChainID)
+	buf.Write(ts)
+	for _, id := range extIDs {
+		h := sha256.Sum256(id)
+		buf.Write(h[:])
+	}
+	h := sha256.Sum256(content)
+	buf.Write(h[:])
+	return buf.Bytes()
+}
+
+// IsValidSignedEntry returns true if the entry is a properly formatted identity signed entry with a verifiable
+// signature.
+// Note: does not check that the signer key was valid for the signer identity at the time of publishing, see
+// ValidateSignedEntry.
+func IsValidSignedEntry(e *Entry) bool {
+	if len(e.ExtIDs) < SignedEntryExtIDs || string(e.ExtIDs[0]) != "SignedEntry" {
+		return false
+	}
+	if len(e.ExtIDs[1]) != 8 || len(e.ExtIDs[2]) != ed.SignatureSize || len(e.ExtIDs[4]) != 64 {
+		return false
+	}
+	signerPubString := string(e.ExtIDs[3])
+	if IdentityKeyStringType(signerPubString) != IDPub {
+		return false
+	}
+	var signerKey [ed.PublicKeySize]byte
+	copy(signerKey[:], base58.Decode(signerPubString)[IDKeyPrefixLength:IDKeyBodyLength])
+	var signature [ed.SignatureSize]byte
+	copy(signature[:], e.ExtIDs[2])
+
+	msg := signedEntryMessage(e.ChainID, string(e.ExtIDs[4]), e.ExtIDs[1], e.ExtIDs[SignedEntryExtIDs:], e.Content)
+	return ed.Verify(&signerKey, msg, &signature)
+}
+
+// SignedEntryPayload returns the entry without the reserved ExtIDs of an
+// identity signed entry, or nil if e is not an identity signed entry.
+func SignedEntryPayload(e *Entry) *Entry {
+	if len(e.ExtIDs) < SignedEntryExtIDs || string(e.ExtIDs[0]) != "SignedEntry" {
+		return nil
+	}
+	p := new(Entry)
+	p.ChainID = e.ChainID
+	p.ExtIDs = e.ExtIDs[SignedEntryExtIDs:]
+	p.Content = e.Content
+	return p
+}
+
+// ValidateSignedEntry validates an identity signed entry that was published at
+// the block height, including that the signer key was active for the signer
+// identity at that height. An error is only returned if the signer identity
+// could not be resolved.
+func ValidateSignedEntry(e *Entry, height int64) (*IdentityEntryVerdict, error) {
+	return validateSignedEntry(e, height, fetchIdentityKeys)
+}
+
+// ValidateSignedEntry is ValidateSignedEntry with signer identities resolved
+// through the cache.
+func (c *IdentityKeyCache) ValidateSignedEntry(e *Entry, height int64) (*IdentityEntryVerdict, error) {
+	return validateSignedEntry(e, height, c.keysAt)
+}
+
+func validateSignedEntry(e *Entry, height int64, keysAt identityKeysFunc) (*IdentityEntryVerdict, error) {
+	v := &IdentityEntryVerdict{Status: IdentityEntryBadSignature, Height: height, SignerLevel: -1}
+	if !IsValidSignedEntry(e) {
+		return v, nil
+	}
+	v.SignerKey = string(e.ExtIDs[3])
+	v.SignerChainID = string(e.ExtIDs[4])
+	return v, checkSignerKey(v, keysAt)
+}
+
+// VerifiedEntry is an identity signed entry that passed validation. Entry is
+// the payload without the reserved ExtIDs.
+type VerifiedEntry struct {
+	*Entry
+	EntryHash     string
+	Height        int64
+	Timestamp     time.Time
+	SignerChainID string
+	SignerKey     string
+	SignerLevel   int
+}
+
+// VerifiedChainReader reads the entries of a chain that are identity signed
+// entries by an allowed identity, with a signer key that was active at the
+// height of the entry. All other entries are skipped.
+type VerifiedChainReader struct {
+	ChainID string
+	Cache   *IdentityKeyCache
+
+	allowed map[string]bool
+}
+
+// NewVerifiedChainReader returns a VerifiedChainReader for the chain that
+// accepts entries signed by the allowed identity chains, or by any identity if
+// allowed is empty. Signer identities are resolved through cache, or a new in
+// memory IdentityKeyCache if cache is nil.
+func NewVerifiedChainReader(chainID string, allowed []string, cache *IdentityKeyCache) *VerifiedChainReader {
+	if cache == nil {
+		cache = NewIdentityKeyCache(nil)
+	}
+	r := new(VerifiedChainReader)
+	r.ChainID = chainID
+	r.Cache = cache
+	r.allowed = make(map[string]bool)
+	for _, id := range allowed {
+		r.allowed[id] = true
+	}
+	return r
+}
+
+// Allowed returns true if entries signed by the identity are accepted.
+func (r *VerifiedChainReader) Allowed(identityChainID string) bool {
+	return len(r.allowed) == 0 || r.allowed[identityChainID]
+}
+
+// Entries returns the verified entries of the chain up to the block height, in
+// chain order. Entries signed outside of the SignedEntryWindow around their
+// block time, and later copies of an entry, are skipped.
+func (r *VerifiedChainReader) Entries(height int64) ([]*VerifiedEntry, error) {
+	entries, err := getChainEntriesWithHeights(r.ChainID, height)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	es := make([]*VerifiedEntry, 0)
+	for _, e := range entries {
+		if p := SignedEntryPayload(e.Entry); p == nil || !r.Allowed(string(e.ExtIDs[4])) {
+			continue
+		}
+		if seen[e.Hash] {
+			continue
+		}
+		if len(e.ExtIDs[1]) != 8 {
+			continue
+		}
+		ts := time.Unix(int64(binary.BigEndian.Uint64(e.ExtIDs[1])), 0)
+		if d := ts.Sub(time.Unix(e.Timestamp, 0)); d > SignedEntryWindow || d < -SignedEntryWindow {
+			continue
+		}
+		v, err := r.Cache.ValidateSignedEntry(e.Entry, e.Height)
+		if err != nil {
+			return nil, err
+		}
+		if !v.Valid() {
+			continue
+		}
+		seen[e.Hash] = true
+		es = append(es, &VerifiedEntry{
+			Entry:         SignedEntryPayload(e.Entry),
+			EntryHash:     e.Hash,
+			Height:        e.Height,
+			Timestamp:     ts,
+			SignerChainID: v.SignerChainID,
+			SignerKey:     v.SignerKey,
+			SignerLevel:   v.SignerLevel,
+		})
+	}
+	return es, nil
+}
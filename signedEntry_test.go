@@ -0,0 +1,140 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestSignedEntry(t *testing.T) {
+	k := testIdentityKeys(t)
+	chainID := GetIdentityChainID([]string{"app"})
+
+	e := new(Entry)
+	e.ChainID = chainID
+	e.ExtIDs = [][]byte{[]byte("note"), []byte("1")}
+	e.Content = []byte("hello")
+
+	s := NewSignedEntry(e, k[0], GetIdentityChainID([]string{"Signer"}))
+	if !IsValidSignedEntry(s) {
+		t.Fatal("expected a valid signed entry")
+	}
+	if len(s.ExtIDs) != SignedEntryExtIDs+2 || string(s.ExtIDs[3]) != k[0].PubString() {
+		t.Errorf("unexpected ExtIDs %q", s.ExtIDs)
+	}
+
+	p := SignedEntryPayload(s)
+	if p == nil || p.ChainID != chainID || len(p.ExtIDs) != 2 || !bytes.Equal(p.ExtIDs[0], e.ExtIDs[0]) || !bytes.Equal(p.Content, e.Content) {
+		t.Errorf("unexpected payload %v", p)
+	}
+	if SignedEntryPayload(e) != nil || IsValidSignedEntry(e) {
+		t.Error("expected an unsigned entry to be rejected")
+	}
+
+	tamper := []func(*Entry){
+		func(x *Entry) { x.Content = []byte("goodbye") },
+		func(x *Entry) { x.ExtIDs[6] = []byte("2") },
+		func(x *Entry) { x.ChainID = GetIdentityChainID([]string{"other"}) },
+		func(x *Entry) { x.ExtIDs[4] = []byte(GetIdentityChainID([]string{"other"})) },
+		func(x *Entry) { x.ExtIDs[1] = []byte{0, 0, 0, 0, 0, 0, 0, 1} },
+	}
+	for i, f := range tamper {
+		x := NewSignedEntry(e, k[0], GetIdentityChainID([]string{"Signer"}))
+		f(x)
+		if IsValidSignedEntry(x) {
+			t.Errorf("tampered entry %d is valid", i)
+		}
+	}
+}
+
+func TestVerifiedChainReader(t *testing.T) {
+	k := testIdentityKeys(t)
+	f := newFakeFactomd()
+
+	alice, _ := NewIdentityChain([]string{"Alice"}, []string{k[0].PubString(), k[1].PubString()})
+	bob, _ := NewIdentityChain([]string{"Bob"}, []string{k[2].PubString()})
+	f.addBlock(10, alice.FirstEntry)
+	f.addBlock(10, bob.FirstEntry)
+
+	// alice's k1 is replaced by k3 at height 20
+	r, _ := NewIdentityKeyReplacementEntry(alice.ChainID, k[1].PubString(), k[3].PubString(), k[0])
+	f.addBlock(20, r)
+
+	first := new(Entry)
+	first.ExtIDs = [][]byte{[]byte("app")}
+	first.ChainID = NewChain(first).ChainID
+	f.addBlock(10, first)
+
+	signed := func(content string, key *IdentityKey, signerChainID string) *Entry {
+		e := new(Entry)
+		e.ChainID = first.ChainID
+		e.Content = []byte(content)
+		return NewSignedEntry(e, key, signerChainID)
+	}
+	tampered := signed("tampered", k[0], alice.ChainID)
+	tampered.Content = []byte("changed")
+
+	f.addBlock(15,
+		signed("alice k1", k[1], alice.ChainID),
+		signed("bob", k[2], bob.ChainID),
+	)
+	aliceK3 := signed("alice k3", k[3], alice.ChainID)
+	f.addBlock(25,
+		signed("alice retired k1", k[1], alice.ChainID),
+		aliceK3,
+		signed("alice key of bob", k[0], bob.ChainID),
+		tampered,
+	)
+
+	// a replayed entry and an entry signed long before its block are skipped
+	f.addBlock(30, aliceK3)
+	f.addBlockAt(35, time.Now().Add(2*SignedEntryWindow), signed("alice stale", k[0], alice.ChainID))
+
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+	SetFactomdServer(ts.URL[7:])
+
+	tests := []struct {
+		name    string
+		allowed []string
+		height  int64
+		content []string
+	}{
+		{"alice", []string{alice.ChainID}, 100, []string{"alice k1", "alice k3"}},
+		{"anyone", nil, 100, []string{"alice k1", "bob", "alice k3"}},
+		{"bob", []string{bob.ChainID}, 100, []string{"bob"}},
+		{"anyone before height", nil, 20, []string{"alice k1", "bob"}},
+	}
+	for _, tt := range tests {
+		es, err := NewVerifiedChainReader(first.ChainID, tt.allowed, nil).Entries(tt.height)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var content []string
+		for _, e := range es {
+			content = append(content, string(e.Content))
+		}
+		if len(content) != len(tt.content) {
+			t.Errorf("%s: expected %q, found %q", tt.name, tt.content, content)
+			continue
+		}
+		for i := range content {
+			if content[i] != tt.content[i] {
+				t.Errorf("%s: expected %q, found %q", tt.name, tt.content, content)
+				break
+			}
+		}
+	}
+
+	es, _ := NewVerifiedChainReader(first.ChainID, []string{alice.ChainID}, nil).Entries(100)
+	if len(es) == 2 && (es[1].SignerKey != k[3].PubString() || es[1].SignerLevel != 1 || es[1].Height != 25 || len(es[1].ExtIDs) != 0) {
+		t.Errorf("unexpected verified entry %+v", es[1])
+	}
+}
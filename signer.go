@@ -0,0 +1,132 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"fmt"
+
+	ed "github.com/FactomProject/ed25519"
+)
+
+// Signer signs messages with an ed25519 key. The same key may act as a
+// Factoid address, an Entry Credit address, or an Identity Key. Implementations
+// may hold the secret key outside of the application process, in which case
+// Sign can fail.
+type Signer interface {
+	// PubBytes returns the 32 byte ed25519 public key.
+	PubBytes() []byte
+
+	// Sign returns the 64 byte ed25519 signature of msg.
+	Sign(msg []byte) ([]byte, error)
+}
+
+// KeySigner is a Signer that holds the secret key in memory.
+type KeySigner struct {
+	Pub *[ed.PublicKeySize]byte
+	Sec *[ed.PrivateKeySize]byte
+}
+
+// NewKeySigner returns a KeySigner for the 32 byte secret key sec.
+func NewKeySigner(sec []byte) (*KeySigner, error) {
+	if len(sec) != 32 {
+		return nil, fmt.Errorf("secret key portion must be 32 bytes")
+	}
+
+	s := new(KeySigner)
+	s.Sec = new([ed.PrivateKeySize]byte)
+	copy(s.Sec[:], sec)
+	s.Pub = ed.GetPublicKey(s.Sec)
+	return s, nil
+}
+
+func (s *KeySigner) PubBytes() []byte {
+	return s.Pub[:]
+}
+
+func (s *KeySigner) Sign(msg []byte) ([]byte, error) {
+	return ed.Sign(s.Sec, msg)[:], nil
+}
+
+// Signer returns a Signer for the Entry Credit address key.
+func (a *ECAddress) Signer() Signer {
+	return &KeySigner{Pub: a.Pub, Sec: a.Sec}
+}
+
+// Signer returns a Signer for the Factoid address key.
+func (a *FactoidAddress) Signer() Signer {
+	return &KeySigner{Pub: a.RCD.(*RCD1).Pub, Sec: a.Sec}
+}
+
+// Signer returns a Signer for the Identity Key.
+func (k *IdentityKey) Signer() Signer {
+	return &KeySigner{Pub: k.Pub, Sec: k.Sec}
+}
+
+// SignerFactoidAddress returns the public Factoid address (FA...) of the
+// Signer key.
+func SignerFactoidAddress(s Signer) string {
+	r := NewRCD1()
+	copy(r.Pub[:], s.PubBytes())
+	return fctAddressString(r.Hash())
+}
+
+// SignerECAddress returns the public Entry Credit address (EC...) of the
+// Signer key.
+func SignerECAddress(s Signer) string {
+	a := NewECAddress()
+	copy(a.Pub[:], s.PubBytes())
+	return a.PubString()
+}
+
+// SignerIdentityKey returns the public Identity Key (idpub...) of the Signer
+// key.
+func SignerIdentityKey(s Signer) string {
+	k := NewIdentityKey()
+	copy(k.Pub[:], s.PubBytes())
+	return k.PubString()
+}
+
+// SignerKey lists the public addresses of a Signer key.
+type SignerKey struct {
+	FactoidAddress string `json:"fct-address"`
+	ECAddress      string `json:"ec-address"`
+	IdentityKey    string `json:"identity-key"`
+}
+
+// NewSignerKey returns the public addresses of the Signer key.
+func NewSignerKey(s Signer) *SignerKey {
+	k := new(SignerKey)
+	k.FactoidAddress = SignerFactoidAddress(s)
+	k.ECAddress = SignerECAddress(s)
+	k.IdentityKey = SignerIdentityKey(s)
+	return k
+}
+
+// signWith signs msg with s and checks that the signature is valid for the
+// Signer public key.
+func signWith(s Signer, msg []byte) ([]byte, error) {
+	pub := s.PubBytes()
+	if len(pub) != ed.PublicKeySize {
+		return nil, fmt.Errorf("signer public key must be %d bytes", ed.PublicKeySize)
+	}
+
+	sig, err := s.Sign(msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != ed.SignatureSize {
+		return nil, fmt.Errorf("signature must be %d bytes", ed.SignatureSize)
+	}
+
+	p := new([ed.PublicKeySize]byte)
+	copy(p[:], pub)
+	sg := new([ed.SignatureSize]byte)
+	copy(sg[:], sig)
+	if !ed.Verify(p, msg, sg) {
+		return nil, fmt.Errorf("signer returned an invalid signature")
+	}
+
+	return sig, nil
+}
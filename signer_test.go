@@ -0,0 +1,180 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factom"
+)
+
+type failingSigner struct {
+	Signer
+}
+
+func (s *failingSigner) Sign(msg []byte) ([]byte, error) {
+	return nil, errors.New("signer is locked")
+}
+
+type badSigner struct {
+	Signer
+}
+
+func (s *badSigner) Sign(msg []byte) ([]byte, error) {
+	return make([]byte, 64), nil
+}
+
+func TestKeySigner(t *testing.T) {
+	ecAddr, _ := GetECAddress("Es2Rf7iM6PdsqfYCo3D1tnAR65SkLENyWJG1deUzpRMQmbh9F3eG")
+
+	s, err := NewKeySigner(ecAddr.SecBytes()[:32])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(s.PubBytes(), ecAddr.PubBytes()) {
+		t.Error("signer public key does not match the address")
+	}
+	if a := SignerECAddress(s); a != ecAddr.String() {
+		t.Errorf("wrong EC address %s", a)
+	}
+
+	msg := []byte("test message")
+	sig, err := s.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, ecAddr.Sign(msg)[:]) {
+		t.Error("signer signature does not match the address signature")
+	}
+
+	if _, err := NewKeySigner(make([]byte, 31)); err == nil {
+		t.Error("expected an error for a short secret key")
+	}
+}
+
+func TestSignerAddresses(t *testing.T) {
+	fa, _ := GetFactoidAddress("Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj")
+	id, _ := GetIdentityKey("idsec2rWrfNTD1x9HPPesA3fz8dmMNZdjmSBULHx8VTXE1J4D9icmAK")
+
+	if a := SignerFactoidAddress(fa.Signer()); a != fa.String() {
+		t.Errorf("wrong Factoid address %s", a)
+	}
+	if k := SignerIdentityKey(id.Signer()); k != id.String() {
+		t.Errorf("wrong identity key %s", k)
+	}
+}
+
+func TestComposeEntryCommitWithSigner(t *testing.T) {
+	ecAddr, _ := GetECAddress("Es2Rf7iM6PdsqfYCo3D1tnAR65SkLENyWJG1deUzpRMQmbh9F3eG")
+	ent := new(Entry)
+	ent.ChainID = "954d5a49fd70d9b8bcdb35d252267829957f7ef7fa6c74f88419bdc5e82209f4"
+	ent.Content = []byte("test!")
+	ent.ExtIDs = append(ent.ExtIDs, []byte("test"))
+
+	ts := time.Unix(1500000000, 123e6)
+	expected, err := ComposeEntryCommitAt(ent, ecAddr, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ComposeEntryCommitWithSigner(ent, ecAddr.Signer(), ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result.Params, expected.Params) {
+		t.Errorf("found %s expected %s", result.Params, expected.Params)
+	}
+
+	if _, err := ComposeEntryCommitWithSigner(ent, &failingSigner{ecAddr.Signer()}, ts); err == nil {
+		t.Error("expected an error from a failing signer")
+	}
+	if _, err := ComposeEntryCommitWithSigner(ent, &badSigner{ecAddr.Signer()}, ts); err == nil {
+		t.Error("expected an error for an invalid signature")
+	}
+}
+
+func TestSignerDaemon(t *testing.T) {
+	dir, err := ioutil.TempDir("", "factom-signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "signer.sock")
+
+	ecAddr, _ := GetECAddress("Es2Rf7iM6PdsqfYCo3D1tnAR65SkLENyWJG1deUzpRMQmbh9F3eG")
+	id, _ := GetIdentityKey("idsec2rWrfNTD1x9HPPesA3fz8dmMNZdjmSBULHx8VTXE1J4D9icmAK")
+
+	d := NewSignerDaemon(ecAddr.Signer(), id.Signer())
+	done := make(chan error)
+	go func() {
+		done <- d.ListenAndServe(path)
+	}()
+	defer func() {
+		d.Close()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}()
+
+	// wait for the socket to be created
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := CheckSignerSocket(path); err != nil {
+		t.Error(err)
+	}
+	if err := CheckSignerSocket(dir); err == nil {
+		t.Error("expected an error for a directory")
+	}
+
+	signers, err := DialSigners(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signers) != 2 {
+		t.Fatalf("expected 2 signers, found %d", len(signers))
+	}
+
+	s := NewSocketSigner(path, ecAddr.PubBytes())
+	ent := new(Entry)
+	ent.ChainID = "954d5a49fd70d9b8bcdb35d252267829957f7ef7fa6c74f88419bdc5e82209f4"
+	ent.Content = []byte("test!")
+	ts := time.Unix(1500000000, 123e6)
+	expected, err := ComposeEntryCommitAt(ent, ecAddr, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ComposeEntryCommitWithSigner(ent, s, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result.Params, expected.Params) {
+		t.Errorf("found %s expected %s", result.Params, expected.Params)
+	}
+
+	ids := NewSocketSigner(path, id.PubBytes())
+	e1, err := NewIdentityAttributeEntryWithSigner(ent.ChainID, ent.ChainID, `[{"key":"a","value":"b"}]`, ids, ent.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2 := NewIdentityAttributeEntry(ent.ChainID, ent.ChainID, `[{"key":"a","value":"b"}]`, id, ent.ChainID)
+	if !bytes.Equal(e1.Hash(), e2.Hash()) {
+		t.Error("attribute entries do not match")
+	}
+
+	unknown := NewSocketSigner(path, make([]byte, 32))
+	if _, err := unknown.Sign([]byte("test")); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
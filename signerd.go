@@ -0,0 +1,294 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SignerTimeout is the time allowed for a SocketSigner request to the
+// SignerDaemon.
+var SignerTimeout = 10 * time.Second
+
+type signerKeysResponse struct {
+	Keys []string `json:"keys"`
+}
+
+type signerSignRequest struct {
+	Key  string `json:"key"`
+	Data string `json:"data"`
+}
+
+type signerSignResponse struct {
+	Signature string `json:"signature"`
+}
+
+// SignerDaemon holds a set of Signers and serves signing requests from other
+// processes over a local Unix socket, so the secret keys never enter the
+// application process. Requests and responses are newline delimited JSON-RPC
+// 2.0 objects with the methods "keys" and "sign".
+type SignerDaemon struct {
+	sync.RWMutex
+	signers  map[string]Signer
+	listener net.Listener
+	path     string
+}
+
+// NewSignerDaemon returns a SignerDaemon serving the provided Signers.
+func NewSignerDaemon(signers ...Signer) *SignerDaemon {
+	d := new(SignerDaemon)
+	d.signers = make(map[string]Signer)
+	for _, s := range signers {
+		d.AddSigner(s)
+	}
+	return d
+}
+
+// AddSigner adds a Signer to the daemon.
+func (d *SignerDaemon) AddSigner(s Signer) {
+	d.Lock()
+	defer d.Unlock()
+	d.signers[hex.EncodeToString(s.PubBytes())] = s
+}
+
+// ListenAndServe listens on the Unix socket at path and serves requests until
+// Close is called. An existing file at path is replaced and the socket is only
+// accessible to the owner of the process.
+func (d *SignerDaemon) ListenAndServe(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// the socket is created in a private directory and only moved to path
+	// once its permissions are set, so that no other user can connect to it
+	// in between
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".signerd")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "socket")
+	l, err := net.Listen("unix", tmp)
+	if err != nil {
+		return err
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0600); err != nil {
+		l.Close()
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		l.Close()
+		return err
+	}
+
+	d.Lock()
+	d.path = path
+	d.Unlock()
+	return d.Serve(l)
+}
+
+// Serve accepts connections on l and serves requests until Close is called.
+func (d *SignerDaemon) Serve(l net.Listener) error {
+	d.Lock()
+	d.listener = l
+	d.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			d.RLock()
+			closed := d.listener == nil
+			d.RUnlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		go d.serveConn(conn)
+	}
+}
+
+// Close stops the daemon from accepting new connections.
+func (d *SignerDaemon) Close() error {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.listener == nil {
+		return nil
+	}
+	err := d.listener.Close()
+	d.listener = nil
+	if d.path != "" {
+		os.Remove(d.path)
+		d.path = ""
+	}
+	return err
+}
+
+func (d *SignerDaemon) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	for {
+		req := new(JSON2Request)
+		if err := dec.Decode(req); err != nil {
+			return
+		}
+
+		resp := NewJSON2Response()
+		resp.ID = req.ID
+		if result, err := d.handle(req); err != nil {
+			resp.Error = err
+		} else if p, err := json.Marshal(result); err != nil {
+			resp.Error = NewJSONError(-32603, "Internal error", err.Error())
+		} else {
+			resp.Result = p
+		}
+
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (d *SignerDaemon) handle(req *JSON2Request) (interface{}, *JSONError) {
+	d.RLock()
+	defer d.RUnlock()
+
+	switch req.Method {
+	case "keys":
+		resp := new(signerKeysResponse)
+		for k := range d.signers {
+			resp.Keys = append(resp.Keys, k)
+		}
+		return resp, nil
+	case "sign":
+		params := new(signerSignRequest)
+		if err := json.Unmarshal(req.Params, params); err != nil {
+			return nil, NewJSONError(-32602, "Invalid params", err.Error())
+		}
+		s, ok := d.signers[params.Key]
+		if !ok {
+			return nil, NewJSONError(-32602, "Invalid params", "unknown key "+params.Key)
+		}
+		data, err := hex.DecodeString(params.Data)
+		if err != nil {
+			return nil, NewJSONError(-32602, "Invalid params", err.Error())
+		}
+		sig, err := s.Sign(data)
+		if err != nil {
+			return nil, NewJSONError(-32603, "Internal error", err.Error())
+		}
+		return &signerSignResponse{Signature: hex.EncodeToString(sig)}, nil
+	}
+
+	return nil, NewJSONError(-32601, "Method not found", nil)
+}
+
+// CheckSignerSocket returns an error unless path is a Unix socket that is only
+// accessible to its owner, as created by SignerDaemon.ListenAndServe. A socket
+// that the process can connect to with these permissions belongs to the same
+// user, so another user cannot have the process sign with their daemon.
+func CheckSignerSocket(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s is not a Unix socket", path)
+	}
+	if fi.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s is accessible to other users", path)
+	}
+	return nil
+}
+
+// SocketSigner is a Signer for a key held by a SignerDaemon listening on a
+// Unix socket.
+type SocketSigner struct {
+	Path string
+	Pub  []byte
+}
+
+// NewSocketSigner returns a SocketSigner for the public key pub served by the
+// SignerDaemon at path.
+func NewSocketSigner(path string, pub []byte) *SocketSigner {
+	return &SocketSigner{Path: path, Pub: pub}
+}
+
+// DialSigners returns a SocketSigner for every key served by the SignerDaemon
+// at path. DialSigners trusts the daemon at path; use CheckSignerSocket first
+// if path comes from an untrusted source.
+func DialSigners(path string) ([]*SocketSigner, error) {
+	resp := new(signerKeysResponse)
+	if err := signerRequest(path, "keys", nil, resp); err != nil {
+		return nil, err
+	}
+
+	signers := make([]*SocketSigner, 0, len(resp.Keys))
+	for _, k := range resp.Keys {
+		pub, err := hex.DecodeString(k)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, NewSocketSigner(path, pub))
+	}
+	return signers, nil
+}
+
+func (s *SocketSigner) PubBytes() []byte {
+	return s.Pub
+}
+
+func (s *SocketSigner) Sign(msg []byte) ([]byte, error) {
+	params := &signerSignRequest{
+		Key:  hex.EncodeToString(s.Pub),
+		Data: hex.EncodeToString(msg),
+	}
+	resp := new(signerSignResponse)
+	if err := signerRequest(s.Path, "sign", params, resp); err != nil {
+		return nil, err
+	}
+
+	return hex.DecodeString(resp.Signature)
+}
+
+func signerRequest(path, method string, params, result interface{}) error {
+	conn, err := net.DialTimeout("unix", path, SignerTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(SignerTimeout))
+
+	req := NewJSON2Request(method, APICounter(), params)
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return err
+	}
+
+	resp := NewJSON2Response()
+	if err := json.NewDecoder(conn).Decode(resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if resp.Result == nil {
+		return fmt.Errorf("signer returned an empty response")
+	}
+
+	return json.Unmarshal(resp.JSONResult(), result)
+}
@@ -15,6 +15,14 @@ import (
 type TransAddress struct {
 	Address string `json:"address"`
 	Amount  uint64 `json:"amount"`
+	Label   string `json:"label,omitempty"`
+}
+
+func (a *TransAddress) name() string {
+	if a.Label == "" {
+		return a.Address
+	}
+	return fmt.Sprintf("%s (%s)", a.Address, a.Label)
 }
 
 type Transaction struct {
@@ -51,21 +59,21 @@ func (tx *Transaction) String() (s string) {
 	for _, in := range tx.Inputs {
 		s += fmt.Sprintln(
 			"Input:",
-			in.Address,
+			in.name(),
 			FactoshiToFactoid(in.Amount),
 		)
 	}
 	for _, out := range tx.Outputs {
 		s += fmt.Sprintln(
 			"Output:",
-			out.Address,
+			out.name(),
 			FactoshiToFactoid(out.Amount),
 		)
 	}
 	for _, ec := range tx.ECOutputs {
 		s += fmt.Sprintln(
 			"ECOutput:",
-			ec.Address,
+			ec.name(),
 			FactoshiToFactoid(ec.Amount),
 		)
 	}
@@ -602,35 +610,62 @@ func BuyExactEC(from, to string, amount uint64, force bool) (*Transaction, error
 		return nil, err
 	}
 
+	name, _, err := newECPurchaseTransaction(from, to, amount, rate)
+	if err != nil {
+		return nil, err
+	}
+	return signAndSendTransaction(name, force)
+}
+
+// newECPurchaseTransaction creates a wallet transaction, with its fee, that
+// buys amount Entry Credits at the Entry Credit rate. It returns the name of
+// the unsigned transaction.
+func newECPurchaseTransaction(from, to string, amount, rate uint64) (string, *Transaction, error) {
 	n := make([]byte, 16)
 	if _, err := rand.Read(n); err != nil {
-		return nil, err
+		return "", nil, err
 	}
 	name := hex.EncodeToString(n)
 
 	if _, err := NewTransaction(name); err != nil {
-		return nil, err
+		return "", nil, err
 	}
 	if _, err := AddTransactionInput(name, from, amount*rate); err != nil {
-		return nil, err
+		return "", nil, discardTransaction(name, err)
 	}
 	if _, err := AddTransactionECOutput(name, to, amount*rate); err != nil {
-		return nil, err
+		return "", nil, discardTransaction(name, err)
 	}
-	if _, err := AddTransactionFee(name, from); err != nil {
-		return nil, err
+	tx, err := AddTransactionFee(name, from)
+	if err != nil {
+		return "", nil, discardTransaction(name, err)
 	}
+	return name, tx, nil
+}
+
+// signAndSendTransaction signs and sends the wallet transaction. The
+// transaction is deleted from the wallet if either step fails.
+func signAndSendTransaction(name string, force bool) (*Transaction, error) {
 	if _, err := SignTransaction(name, force); err != nil {
-		return nil, err
+		return nil, discardTransaction(name, err)
 	}
 	r, err := SendTransaction(name)
 	if err != nil {
-		return nil, err
+		return nil, discardTransaction(name, err)
 	}
 
 	return r, nil
 }
 
+// discardTransaction deletes the wallet transaction after err and returns err,
+// or an error that includes both if the transaction could not be deleted.
+func discardTransaction(name string, err error) error {
+	if derr := DeleteTransaction(name); derr != nil {
+		return fmt.Errorf("%v; deleting transaction %s: %v", err, name, derr)
+	}
+	return err
+}
+
 type TransactionResponse struct {
 	ECTranasction      interface{} `json:"ectransaction,omitempty"`
 	FactoidTransaction interface{} `json:"factoidtransaction,omitempty"`
@@ -22,6 +22,10 @@ const (
 	ZeroHash = "0000000000000000000000000000000000000000000000000000000000000000"
 )
 
+// CommitWindow is how far the timestamp of an Entry or Chain commit may be
+// from the time factomd receives it before the commit is rejected.
+const CommitWindow = time.Hour
+
 var (
 	RpcConfig = &RPCConfig{}
 )
@@ -33,7 +37,16 @@ func EntryCost(e *Entry) (int8, error) {
 	}
 
 	// caulculate the length exluding the header size 35 for Milestone 1
-	l := len(p) - 35
+	return EntryCostFromSize(len(p) - 35)
+}
+
+// EntryCostFromSize returns the number of Entry Credits needed to pay for an
+// Entry with a payload (ExtIDs and Content, excluding the 35 byte header) of l
+// bytes.
+func EntryCostFromSize(l int) (int8, error) {
+	if l < 0 {
+		return 0, fmt.Errorf("Entry size cannot be negative")
+	}
 
 	if l > 10240 {
 		return 10, fmt.Errorf("Entry cannot be larger than 10KB")
@@ -93,13 +106,53 @@ func FactoidToFactoshi(amt string) uint64 {
 	return total
 }
 
-// milliTime returns a 6 byte slice representing the unix time in milliseconds
-func milliTime() (r []byte) {
+// milliTimestamp returns a 6 byte slice representing the unix time of t in
+// milliseconds
+func milliTimestamp(t time.Time) ([]byte, error) {
+	m := t.UnixNano() / 1e6
+	if m < 0 || m >= 1<<48 {
+		return nil, fmt.Errorf("timestamp %v cannot be represented in 6 bytes", t)
+	}
+
 	buf := new(bytes.Buffer)
-	t := time.Now().UnixNano()
-	m := t / 1e6
 	binary.Write(buf, binary.BigEndian, m)
-	return buf.Bytes()[2:]
+	return buf.Bytes()[2:], nil
+}
+
+// ValidateCommitTime returns an error if a commit with timestamp ts would be
+// rejected by factomd when it is submitted at the time now.
+func ValidateCommitTime(ts, now time.Time) error {
+	if d := ts.Sub(now); d > CommitWindow || d < -CommitWindow {
+		return fmt.Errorf(
+			"commit timestamp %v is outside of the %v window around %v",
+			ts, CommitWindow, now)
+	}
+	return nil
+}
+
+// encodeVarInt encodes v as a Factom varint; big endian groups of 7 bits with
+// the high bit set on every byte but the last.
+func encodeVarInt(v uint64) []byte {
+	buf := []byte{byte(v & 0x7f)}
+	for v >>= 7; v > 0; v >>= 7 {
+		buf = append([]byte{byte(v&0x7f) | 0x80}, buf...)
+	}
+	return buf
+}
+
+// decodeVarInt decodes a Factom varint and returns the remaining data.
+func decodeVarInt(data []byte) (uint64, []byte, error) {
+	var v uint64
+	for i, b := range data {
+		if i == 10 {
+			break
+		}
+		v = v<<7 | uint64(b&0x7f)
+		if b&0x80 == 0 {
+			return v, data[i+1:], nil
+		}
+	}
+	return 0, nil, fmt.Errorf("invalid varint")
 }
 
 // shad Double Sha256 Hash; sha256(sha256(data))
@@ -7,6 +7,7 @@ package factom_test
 import (
 	. "github.com/FactomProject/factom"
 	"testing"
+	"time"
 )
 
 func TestFactoidToFactoshi(t *testing.T) {
@@ -42,3 +43,44 @@ func TestFactoidToFactoshi(t *testing.T) {
 		t.Errorf("r5=%d expecting %d", r5, e5)
 	}
 }
+
+func TestValidateCommitTime(t *testing.T) {
+	now := time.Now()
+
+	if err := ValidateCommitTime(now, now); err != nil {
+		t.Error(err)
+	}
+	if err := ValidateCommitTime(now.Add(-59*time.Minute), now); err != nil {
+		t.Error(err)
+	}
+	if err := ValidateCommitTime(now.Add(61*time.Minute), now); err == nil {
+		t.Error("expected commit an hour in the future to be invalid")
+	}
+	if err := ValidateCommitTime(now.Add(-2*time.Hour), now); err == nil {
+		t.Error("expected commit two hours in the past to be invalid")
+	}
+}
+
+func TestEntryCostFromSize(t *testing.T) {
+	sizes := map[int]int8{
+		0:     1,
+		1:     1,
+		1024:  1,
+		1025:  2,
+		10240: 10,
+	}
+	for l, e := range sizes {
+		if c, err := EntryCostFromSize(l); err != nil {
+			t.Error(err)
+		} else if c != e {
+			t.Errorf("size %d cost %d expecting %d", l, c, e)
+		}
+	}
+
+	if _, err := EntryCostFromSize(10241); err == nil {
+		t.Error("expected an error for an entry larger than 10KB")
+	}
+	if _, err := EntryCostFromSize(-1); err == nil {
+		t.Error("expected an error for a negative size")
+	}
+}
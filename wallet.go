@@ -7,18 +7,170 @@ package factom
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // BackupWallet returns a formatted string with the wallet seed and the secret
 // keys for all of the wallet addresses.
 func BackupWallet() (string, error) {
+	return backupWallet(nil)
+}
+
+// BackupWalletEncrypted returns the wallet backup like BackupWallet with each
+// of the secret keys encrypted with password. The wallet seed and its
+// passphrase have no encrypted format and are left out of the backup.
+func BackupWalletEncrypted(password string) (string, error) {
+	params := new(struct {
+		Password string `json:"password"`
+	})
+	params.Password = password
+	return backupWallet(params)
+}
+
+// BackupWalletShares splits the wallet seed into n shares, any m of which
+// restore the wallet with RestoreWalletFromShares. The shares do not hold the
+// seed passphrase or derivation paths, which must be kept separately.
+func BackupWalletShares(m, n int) ([]string, error) {
+	params := new(struct {
+		Threshold int `json:"threshold"`
+		Shares    int `json:"shares"`
+	})
+	params.Threshold = m
+	params.Shares = n
+
+	req := NewJSON2Request("wallet-backup-shares", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(struct {
+		Shares []string `json:"shares"`
+	})
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return r.Shares, nil
+}
+
+// RestoreWalletFromShares replaces the seed of an empty wallet with the seed
+// recovered from shares and the options it was used with, and restores the
+// addresses that have been used, with a gap limit of gapLimit unused addresses
+// (0 for the default). opts may be nil to use the default derivation.
+func RestoreWalletFromShares(opts *DerivationOptions, gapLimit uint32, shares ...string) error {
+	params := new(struct {
+		Shares   []string `json:"shares"`
+		GapLimit uint32   `json:"gap-limit,omitempty"`
+		derivationParams
+	})
+	params.Shares = shares
+	params.GapLimit = gapLimit
+	params.derivationParams = newDerivationParams(opts)
+
+	req := NewJSON2Request("restore-seed-shares", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	return nil
+}
+
+// RestoreWalletSeed replaces the seed of an empty wallet with a mnemonic seed
+// and the options it was used with, and restores the addresses that have been
+// used, with a gap limit of gapLimit unused addresses (0 for the default).
+// opts may be nil to use the default derivation.
+func RestoreWalletSeed(mnemonic string, opts *DerivationOptions, gapLimit uint32) error {
+	params := new(struct {
+		Mnemonic string `json:"mnemonic"`
+		GapLimit uint32 `json:"gap-limit,omitempty"`
+		derivationParams
+	})
+	params.Mnemonic = mnemonic
+	params.GapLimit = gapLimit
+	params.derivationParams = newDerivationParams(opts)
+
+	req := NewJSON2Request("restore-seed", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	return nil
+}
+
+// RecoverWalletAddresses scans the addresses derived from the wallet seed and
+// restores the ones that have been used, with a gap limit of gapLimit unused
+// addresses (0 for the default). It returns the restored addresses. A wallet
+// started without a transaction database reads the whole Factoid chain from
+// factomd for the scan.
+func RecoverWalletAddresses(gapLimit uint32) ([]*FactoidAddress, []*ECAddress, error) {
+	params := new(struct {
+		GapLimit uint32 `json:"gap-limit,omitempty"`
+	})
+	params.GapLimit = gapLimit
+
+	req := NewJSON2Request("recover-addresses", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.Error != nil {
+		return nil, nil, resp.Error
+	}
+
+	as := new(multiAddressResponse)
+	if err := json.Unmarshal(resp.JSONResult(), as); err != nil {
+		return nil, nil, err
+	}
+
+	fs := make([]*FactoidAddress, 0)
+	es := make([]*ECAddress, 0)
+	for _, adr := range as.Addresses {
+		switch AddressStringType(adr.Public) {
+		case FactoidPub:
+			f, err := GetFactoidAddress(adr.Secret)
+			if err != nil {
+				return nil, nil, err
+			}
+			fs = append(fs, f)
+		case ECPub:
+			e, err := GetECAddress(adr.Secret)
+			if err != nil {
+				return nil, nil, err
+			}
+			es = append(es, e)
+		default:
+			return nil, nil, fmt.Errorf("%s is not a valid address", adr.Public)
+		}
+	}
+	return fs, es, nil
+}
+
+func backupWallet(params interface{}) (string, error) {
 	type walletBackupResponse struct {
 		Seed         string             `json:"wallet-seed"`
+		Passphrase   string             `json:"wallet-passphrase"`
+		FactoidPath  string             `json:"factoid-path"`
+		ECPath       string             `json:"ec-path"`
+		IdentityPath string             `json:"identity-path"`
 		Addresses    []*addressResponse `json:"addresses"`
 		IdentityKeys []*addressResponse `json:"identity-keys"`
+		Multisig     []*addressResponse `json:"multisig-addresses"`
+		Labels       []*AddressLabel    `json:"labels"`
+		Contacts     []*AddressLabel    `json:"contacts"`
 	}
 
-	req := NewJSON2Request("wallet-backup", APICounter(), nil)
+	req := NewJSON2Request("wallet-backup", APICounter(), params)
 	resp, err := walletRequest(req)
 	if err != nil {
 		return "", err
@@ -32,8 +184,20 @@ func BackupWallet() (string, error) {
 		return "", err
 	}
 
-	s := fmt.Sprintln(w.Seed)
-	s += fmt.Sprintln()
+	s := ""
+	if w.Seed != "" {
+		s += fmt.Sprintln(w.Seed)
+		s += fmt.Sprintln()
+	}
+	if w.Passphrase != "" {
+		s += fmt.Sprintln("Passphrase:", w.Passphrase)
+	}
+	if w.FactoidPath != "" {
+		s += fmt.Sprintln("Factoid Path:", w.FactoidPath)
+		s += fmt.Sprintln("Entry Credit Path:", w.ECPath)
+		s += fmt.Sprintln("Identity Path:", w.IdentityPath)
+		s += fmt.Sprintln()
+	}
 	for _, adr := range w.Addresses {
 		s += fmt.Sprintln(adr.Public)
 		s += fmt.Sprintln(adr.Secret)
@@ -44,6 +208,21 @@ func BackupWallet() (string, error) {
 		s += fmt.Sprintln(k.Secret)
 		s += fmt.Sprintln()
 	}
+	for _, m := range w.Multisig {
+		s += fmt.Sprintln(m.Public)
+		s += fmt.Sprintf("%d of %s\n", m.Required, strings.Join(m.Members, ", "))
+		s += fmt.Sprintln()
+	}
+	for _, l := range w.Labels {
+		s += fmt.Sprintln(l)
+	}
+	if len(w.Contacts) > 0 {
+		s += fmt.Sprintln("Address Book:")
+		s += fmt.Sprintln()
+	}
+	for _, l := range w.Contacts {
+		s += fmt.Sprintln(l)
+	}
 	return s, nil
 }
 
@@ -160,6 +339,248 @@ func ImportAddresses(addrs ...string) (
 	return fs, es, nil
 }
 
+// ImportEncryptedAddresses imports Factoid and Entry Credit secret keys in the
+// encrypted key format (Fenc... or Eenc...) into the wallet. The wallet
+// decrypts them with password and only the public addresses are returned.
+func ImportEncryptedAddresses(password string, secrets ...string) ([]string, error) {
+	params := new(importRequest)
+	params.Password = password
+	for _, sec := range secrets {
+		params.Addresses = append(params.Addresses, secretRequest{Secret: sec})
+	}
+
+	req := NewJSON2Request("import-addresses", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(multiAddressResponse)
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	pubs := make([]string, 0)
+	for _, a := range r.Addresses {
+		pubs = append(pubs, a.Public)
+	}
+	return pubs, nil
+}
+
+// ExportEncryptedKeys returns the secret keys of the wallet addresses and
+// Identity Keys in pubs encrypted with password. If no pubs are given, every
+// key in the wallet is exported.
+func ExportEncryptedKeys(password string, pubs ...string) ([]*EncryptedKey, error) {
+	params := new(struct {
+		Password  string   `json:"password"`
+		Addresses []string `json:"addresses,omitempty"`
+	})
+	params.Password = password
+	params.Addresses = pubs
+
+	req := NewJSON2Request("export-encrypted-keys", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(struct {
+		Addresses []*EncryptedKey `json:"addresses"`
+	})
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return r.Addresses, nil
+}
+
+// ImportMultisigAddress adds an m of n multisig Factoid address made from the
+// public Factoid addresses of its members to the wallet.
+func ImportMultisigAddress(m int, members ...string) (*RCD2, error) {
+	params := new(struct {
+		Required int      `json:"required"`
+		Members  []string `json:"members"`
+	})
+	params.Required = m
+	params.Members = members
+
+	req := NewJSON2Request("import-multisig-address", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(addressResponse)
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return NewRCD2(r.Required, r.Members...)
+}
+
+// FetchMultisigAddresses returns all of the multisig addresses in the wallet.
+func FetchMultisigAddresses() ([]*RCD2, error) {
+	req := NewJSON2Request("multisig-addresses", APICounter(), nil)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(multiAddressResponse)
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	ms := make([]*RCD2, 0)
+	for _, v := range r.Addresses {
+		m, err := NewRCD2(v.Required, v.Members...)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+// FetchMultisigSignatures returns the member signatures collected by the wallet
+// for the multisig input address of the tmp transaction name.
+func FetchMultisigSignatures(name, address string) ([]*MultisigSignature, error) {
+	params := new(struct {
+		Name    string `json:"tx-name"`
+		Address string `json:"address"`
+	})
+	params.Name = name
+	params.Address = address
+
+	req := NewJSON2Request("multisig-signatures", APICounter(), params)
+	return walletMultisigSignaturesRequest(req)
+}
+
+// AddMultisigSignatures adds member signatures made by other parties to the
+// multisig input address of the tmp transaction name, and returns all of the
+// signatures collected for the input.
+func AddMultisigSignatures(name, address string, sigs ...*MultisigSignature) ([]*MultisigSignature, error) {
+	params := new(struct {
+		Name       string               `json:"tx-name"`
+		Address    string               `json:"address"`
+		Signatures []*MultisigSignature `json:"signatures"`
+	})
+	params.Name = name
+	params.Address = address
+	params.Signatures = sigs
+
+	req := NewJSON2Request("add-multisig-signatures", APICounter(), params)
+	return walletMultisigSignaturesRequest(req)
+}
+
+func walletMultisigSignaturesRequest(req *JSON2Request) ([]*MultisigSignature, error) {
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(struct {
+		Signatures []*MultisigSignature `json:"signatures"`
+	})
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return r.Signatures, nil
+}
+
+// AddWalletSigner connects the wallet to the SignerDaemon listening on the Unix
+// socket at path. The wallet can then sign with the daemon keys without holding
+// the secret keys itself. The wallet only accepts sockets that are private to
+// the user running it, see CheckSignerSocket.
+func AddWalletSigner(path string) ([]*SignerKey, error) {
+	params := new(struct {
+		Socket string `json:"socket"`
+	})
+	params.Socket = path
+
+	req := NewJSON2Request("add-signer", APICounter(), params)
+	return walletSignersRequest(req)
+}
+
+// FetchWalletSigners returns the external signer keys known to the wallet.
+func FetchWalletSigners() ([]*SignerKey, error) {
+	req := NewJSON2Request("signers", APICounter(), nil)
+	return walletSignersRequest(req)
+}
+
+func walletSignersRequest(req *JSON2Request) ([]*SignerKey, error) {
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(struct {
+		Signers []*SignerKey `json:"signers"`
+	})
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return r.Signers, nil
+}
+
+// ImportWatchOnlyAddresses adds public Factoid and Entry Credit addresses to
+// the wallet so that their balances and transactions can be followed without
+// the secret keys.
+func ImportWatchOnlyAddresses(addrs ...*WatchOnlyAddress) ([]*WatchOnlyAddress, error) {
+	params := new(struct {
+		Addresses []*WatchOnlyAddress `json:"addresses"`
+	})
+	params.Addresses = addrs
+
+	req := NewJSON2Request("import-watch-only-addresses", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(multiWatchOnlyResponse)
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return r.Addresses, nil
+}
+
+// FetchWatchOnlyAddresses returns all of the watch-only addresses in the
+// wallet.
+func FetchWatchOnlyAddresses() ([]*WatchOnlyAddress, error) {
+	req := NewJSON2Request("watch-only-addresses", APICounter(), nil)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(multiWatchOnlyResponse)
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return r.Addresses, nil
+}
+
 func ImportKoinify(mnemonic string) (*FactoidAddress, error) {
 	params := new(importKoinifyRequest)
 	params.Words = mnemonic
@@ -220,6 +641,10 @@ func FetchAddresses() ([]*FactoidAddress, []*ECAddress, error) {
 	}
 
 	for _, adr := range as.Addresses {
+		// multisig addresses have no secret key
+		if len(adr.Members) > 0 {
+			continue
+		}
 		switch AddressStringType(adr.Public) {
 		case FactoidPub:
 			f, err := GetFactoidAddress(adr.Secret)
@@ -324,6 +749,39 @@ func ImportIdentityKeys(pubs ...string) ([]*IdentityKey, error) {
 	return keys, nil
 }
 
+// ImportEncryptedIdentityKeys imports Identity Keys in the encrypted key
+// format (idenc...) into the wallet. The wallet decrypts them with password and
+// only the public keys are returned.
+func ImportEncryptedIdentityKeys(password string, secrets ...string) ([]string, error) {
+	params := new(struct {
+		IdentityKeys []secretRequest `json:"keys"`
+		Password     string          `json:"password"`
+	})
+	params.Password = password
+	for _, sec := range secrets {
+		params.IdentityKeys = append(params.IdentityKeys, secretRequest{Secret: sec})
+	}
+
+	req := NewJSON2Request("import-identity-keys", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(multiIdentityKeyResponse)
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	pubs := make([]string, 0)
+	for _, v := range r.IdentityKeys {
+		pubs = append(pubs, v.Public)
+	}
+	return pubs, nil
+}
+
 func FetchIdentityKey(pub string) (*IdentityKey, error) {
 	params := new(struct {
 		Public string `json:"public"`
@@ -434,8 +892,10 @@ func UnlockWallet(passphrase string, seconds int64) (int64, error) {
 }
 
 type addressResponse struct {
-	Public string `json:"public"`
-	Secret string `json:"secret"`
+	Public   string   `json:"public"`
+	Secret   string   `json:"secret"`
+	Required int      `json:"required,omitempty"`
+	Members  []string `json:"members,omitempty"`
 }
 
 type multiAddressResponse struct {
@@ -446,6 +906,10 @@ type multiIdentityKeyResponse struct {
 	IdentityKeys []*addressResponse `json:"keys"`
 }
 
+type multiWatchOnlyResponse struct {
+	Addresses []*WatchOnlyAddress `json:"addresses"`
+}
+
 type composeEntryRequest struct {
 	Entry Entry  `json:"entry"`
 	ECPub string `json:"ecpub"`
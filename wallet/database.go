@@ -19,6 +19,9 @@ type Wallet struct {
 	DBPath       string
 	txlock       sync.Mutex
 	transactions map[string]*factoid.Transaction
+	multisigs    map[string]map[string][]*factom.MultisigSignature
+	signerlock   sync.RWMutex
+	signers      map[string]factom.Signer
 	txdb         *TXDatabaseOverlay
 }
 
@@ -162,6 +165,31 @@ func (w *Wallet) GetSeed() (string, error) {
 	return seed.MnemonicSeed, nil
 }
 
+// GetDerivationOptions returns the BIP39 passphrase and the derivation paths
+// used to generate addresses from the Wallet Seed.
+func (w *Wallet) GetDerivationOptions() (*factom.DerivationOptions, error) {
+	seed, err := w.GetDBSeed()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := new(factom.DerivationOptions)
+	opts.Passphrase = seed.Passphrase
+	opts.FactoidPath = seed.FactoidPath
+	opts.ECPath = seed.ECPath
+	opts.IdentityPath = seed.IdentityPath
+	if opts.FactoidPath == nil {
+		opts.FactoidPath = factom.NewFactoidPath(0)
+	}
+	if opts.ECPath == nil {
+		opts.ECPath = factom.NewECPath(0)
+	}
+	if opts.IdentityPath == nil {
+		opts.IdentityPath = factom.NewIdentityPath(0)
+	}
+	return opts, nil
+}
+
 func (w *Wallet) GetVersion() string {
 	return WalletVersion
 }
@@ -0,0 +1,113 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factom"
+)
+
+// AddIdentity adds an identity chain to the identities controlled by the
+// Wallet. The active keys of the identity are resolved from the blockchain and
+// the Wallet must hold at least one of them.
+func (w *Wallet) AddIdentity(chainID string) (*factom.WalletIdentity, error) {
+	i, err := w.resolveIdentity(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if i.SignerKey(-1) == "" {
+		return nil, fmt.Errorf("wallet: Wallet does not hold any active key of identity %s", chainID)
+	}
+	if err := w.InsertWalletIdentity(i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// RefreshIdentity updates the keys of an identity controlled by the Wallet
+// from the blockchain, and whether the Wallet holds each of them.
+func (w *Wallet) RefreshIdentity(chainID string) (*factom.WalletIdentity, error) {
+	if _, err := w.GetWalletIdentity(chainID); err != nil {
+		return nil, err
+	}
+	i, err := w.resolveIdentity(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.InsertWalletIdentity(i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// RefreshIdentities refreshes all of the identities controlled by the Wallet.
+func (w *Wallet) RefreshIdentities() ([]*factom.WalletIdentity, error) {
+	is, err := w.GetAllWalletIdentities()
+	if err != nil {
+		return nil, err
+	}
+	for n, i := range is {
+		if is[n], err = w.RefreshIdentity(i.ChainID); err != nil {
+			return nil, err
+		}
+	}
+	return is, nil
+}
+
+// resolveIdentity returns the identity with its current keys, using the
+// identity states cached in the Wallet database.
+func (w *Wallet) resolveIdentity(chainID string) (*factom.WalletIdentity, error) {
+	s, err := factom.NewIdentityKeyCache(w).Update(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	i := new(factom.WalletIdentity)
+	i.ChainID = chainID
+	i.Name = s.Name
+	i.Height = s.Height
+	for level, key := range s.Keys() {
+		_, err := w.GetIdentitySigner(key)
+		i.Keys = append(i.Keys, &factom.WalletIdentityKey{
+			Level:    level,
+			Key:      key,
+			InWallet: err == nil,
+		})
+	}
+	return i, nil
+}
+
+// GetIdentitySignerKey returns the highest priority key held by the Wallet for
+// an identity it controls that is allowed to sign for a key at the level. Use a
+// negative level when any active key can sign, as for attributes.
+func (w *Wallet) GetIdentitySignerKey(chainID string, level int) (string, error) {
+	i, err := w.GetWalletIdentity(chainID)
+	if err != nil {
+		return "", err
+	}
+	key := i.SignerKey(level)
+	if key == "" && level < 0 {
+		return "", fmt.Errorf("wallet: Wallet does not hold any active key of identity %s", chainID)
+	} else if key == "" {
+		return "", fmt.Errorf("wallet: Wallet does not hold a key of identity %s at level %d or higher", chainID, level)
+	}
+	return key, nil
+}
+
+// GetIdentityReplacementSignerKey returns the highest priority key held by the
+// Wallet that is allowed to replace oldKey in an identity it controls. A key
+// may only be replaced by a signer of the same or a higher priority.
+func (w *Wallet) GetIdentityReplacementSignerKey(chainID, oldKey string) (string, error) {
+	i, err := w.GetWalletIdentity(chainID)
+	if err != nil {
+		return "", err
+	}
+	level := i.Level(oldKey)
+	if level < 0 {
+		return "", fmt.Errorf("wallet: %s is not an active key of identity %s", oldKey, chainID)
+	}
+	return w.GetIdentitySignerKey(chainID, level)
+}
@@ -0,0 +1,214 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet_test
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/FactomProject/factom"
+	. "github.com/FactomProject/factom/wallet"
+)
+
+// identityChain serves a single chain from memory, one Entry Block per call to
+// add. Entries that are expected are added to the chain when they are
+// revealed.
+type identityChain struct {
+	head     string
+	height   int64
+	eblocks  map[string]*factom.EBlock
+	entries  map[string]*factom.Entry
+	expected map[string]*factom.Entry
+
+	// commits is the number of entry commits, and failReveals the number
+	// of entry reveals to reject
+	commits     int
+	failReveals int
+}
+
+func (c *identityChain) expect(es ...*factom.Entry) {
+	if c.expected == nil {
+		c.expected = make(map[string]*factom.Entry)
+	}
+	for _, e := range es {
+		c.expected[hex.EncodeToString(e.Hash())] = e
+	}
+}
+
+func (c *identityChain) add(height int64, e *factom.Entry) {
+	if c.eblocks == nil {
+		c.eblocks = make(map[string]*factom.EBlock)
+		c.entries = make(map[string]*factom.Entry)
+		c.head = factom.ZeroHash
+	}
+	hash := hex.EncodeToString(e.Hash())
+	c.entries[hash] = e
+
+	eb := new(factom.EBlock)
+	eb.Header.ChainID = e.ChainID
+	eb.Header.DBHeight = height
+	eb.Header.PrevKeyMR = c.head
+	eb.EntryList = []factom.EBEntry{{EntryHash: hash}}
+
+	c.head = fmt.Sprintf("%064x", height)
+	c.height = height
+	c.eblocks[c.head] = eb
+}
+
+func (c *identityChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		Method string `json:"method"`
+		Params struct {
+			KeyMR string `json:"keymr"`
+			Hash  string `json:"hash"`
+			Entry string `json:"entry"`
+		} `json:"params"`
+	})
+	json.NewDecoder(r.Body).Decode(req)
+
+	var result interface{}
+	var rpcErr *factom.JSONError
+	switch req.Method {
+	case "chain-head":
+		result = map[string]interface{}{"chainhead": c.head, "chaininprocesslist": false}
+	case "entry-block":
+		result = c.eblocks[req.Params.KeyMR]
+	case "entry":
+		result = c.entries[req.Params.Hash]
+	case "heights":
+		result = map[string]interface{}{"directoryblockheight": c.height}
+	case "commit-entry":
+		c.commits++
+		result = map[string]interface{}{"message": "Entry Commit Success", "txid": ""}
+	case "reveal-entry":
+		if c.failReveals > 0 {
+			c.failReveals--
+			rpcErr = factom.NewJSONError(-32603, "Internal error", nil)
+			break
+		}
+		data, _ := hex.DecodeString(req.Params.Entry)
+		sum := sha512.Sum512(data)
+		h := sha256.Sum256(append(sum[:], data...))
+		hash := hex.EncodeToString(h[:])
+		if e, ok := c.expected[hash]; ok {
+			c.add(c.height+1, e)
+		}
+		result = map[string]interface{}{"message": "Entry Reveal Success", "entryhash": hash}
+	case "ack":
+		status := "Unknown"
+		if _, ok := c.entries[req.Params.Hash]; ok {
+			status = "DBlockConfirmed"
+		}
+		result = map[string]interface{}{"entryhash": req.Params.Hash, "entrydata": map[string]string{"status": status}}
+	}
+
+	resp := factom.NewJSON2Response()
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		resp.Result, _ = json.Marshal(result)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestWalletIdentities(t *testing.T) {
+	var keys []*factom.IdentityKey
+	for _, s := range []string{
+		"idsec2rChEHLz3SPQQx3syQtB11pHAmxyGjux5FntnS7xqTCieHxxTc",
+		"idsec1xuUyeCCrJhsojf2wLAZqRxPzPFR8Gidd9DRRid1yGy8ncAJG3",
+		"idsec2J3nNoqdiyboCBKDGauqN9Jb33dyFSqaJKZqTs6i5FmztsTn5f",
+		"idsec1jztZ7dypqtwtPPWxybZFNpvvpUh6g8oog6Mnk2gGCm1pNBTgE",
+	} {
+		k, err := factom.GetIdentityKey(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k)
+	}
+
+	c, err := factom.NewIdentityChain([]string{"Wallet", "Test"}, []string{keys[0].PubString(), keys[1].PubString(), keys[2].PubString()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := new(identityChain)
+	chain.add(10, c.FirstEntry)
+
+	ts := httptest.NewServer(chain)
+	defer ts.Close()
+	factom.SetFactomdServer(ts.URL[7:])
+
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+
+	if _, err := w1.AddIdentity(c.ChainID); err == nil {
+		t.Error("expected an error for an identity without keys in the wallet")
+	}
+
+	// the wallet holds the second and third priority keys
+	if err := w1.InsertIdentityKey(keys[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.InsertIdentityKey(keys[2]); err != nil {
+		t.Fatal(err)
+	}
+	i, err := w1.AddIdentity(c.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(i.Keys) != 3 || i.Keys[0].InWallet || !i.Keys[1].InWallet || i.Name[1] != "Test" {
+		t.Errorf("unexpected identity %v", i)
+	}
+
+	if k, err := w1.GetIdentitySignerKey(c.ChainID, -1); err != nil || k != keys[1].PubString() {
+		t.Errorf("expected signer %s, found %s %v", keys[1].PubString(), k, err)
+	}
+	if k, err := w1.GetIdentityReplacementSignerKey(c.ChainID, keys[2].PubString()); err != nil || k != keys[1].PubString() {
+		t.Errorf("expected signer %s, found %s %v", keys[1].PubString(), k, err)
+	}
+	if _, err := w1.GetIdentityReplacementSignerKey(c.ChainID, keys[0].PubString()); err == nil {
+		t.Error("expected an error replacing a key of higher priority than the wallet holds")
+	}
+	if _, err := w1.GetIdentityReplacementSignerKey(c.ChainID, keys[3].PubString()); err == nil {
+		t.Error("expected an error replacing an inactive key")
+	}
+
+	// the third key is replaced by a key the wallet does not hold
+	r, err := factom.NewIdentityKeyReplacementEntry(c.ChainID, keys[2].PubString(), keys[3].PubString(), keys[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain.add(20, r)
+
+	is, err := w1.RefreshIdentities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(is) != 1 || is[0].Keys[2].Key != keys[3].PubString() || is[0].Keys[2].InWallet || is[0].Height != 20 {
+		t.Errorf("unexpected identities %v", is)
+	}
+	if _, err := w1.GetIdentityReplacementSignerKey(c.ChainID, keys[3].PubString()); err != nil {
+		t.Error(err)
+	}
+
+	if err := w1.RemoveWalletIdentity(c.ChainID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w1.GetWalletIdentity(c.ChainID); err != ErrNoSuchIdentity {
+		t.Errorf("expected %v, found %v", ErrNoSuchIdentity, err)
+	}
+	if _, err := w1.GetIdentityKey(keys[1].PubString()); err != nil {
+		t.Error("expected the identity keys to be kept")
+	}
+}
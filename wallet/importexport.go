@@ -15,6 +15,13 @@ import (
 // ImportWalletFromMnemonic creates a new wallet with a provided Mnemonic seed
 // defined in bip-0039.
 func ImportWalletFromMnemonic(mnemonic, path string) (*Wallet, error) {
+	return ImportWalletFromMnemonicWithOptions(mnemonic, path, nil)
+}
+
+// ImportWalletFromMnemonicWithOptions creates a new wallet with a provided
+// Mnemonic seed, BIP39 passphrase, and derivation paths. Use it to match the
+// addresses of wallets that were created with non-default options.
+func ImportWalletFromMnemonicWithOptions(mnemonic, path string, opts *factom.DerivationOptions) (*Wallet, error) {
 	mnemonic, err := factom.ParseAndValidateMnemonic(mnemonic)
 	if err != nil {
 		return nil, err
@@ -33,6 +40,12 @@ func ImportWalletFromMnemonic(mnemonic, path string) (*Wallet, error) {
 
 	seed := new(DBSeed)
 	seed.MnemonicSeed = mnemonic
+	if opts != nil {
+		seed.Passphrase = opts.Passphrase
+		seed.FactoidPath = opts.FactoidPath
+		seed.ECPath = opts.ECPath
+		seed.IdentityPath = opts.IdentityPath
+	}
 	if err := db.InsertDBSeed(seed); err != nil {
 		return nil, err
 	}
@@ -44,27 +57,76 @@ func ImportWalletFromMnemonic(mnemonic, path string) (*Wallet, error) {
 	return w, nil
 }
 
+// RestoreSeed replaces the Wallet Seed with a bip-0039 Mnemonic seed and the
+// passphrase and derivation paths it was used with. opts may be nil to use the
+// default derivation. The wallet must not hold any addresses or Identity Keys
+// yet; use RecoverAddresses afterwards to restore the used addresses.
+func (w *Wallet) RestoreSeed(mnemonic string, opts *factom.DerivationOptions) error {
+	mnemonic, err := factom.ParseAndValidateMnemonic(mnemonic)
+	if err != nil {
+		return err
+	}
+
+	fs, es, err := w.GetAllAddresses()
+	if err != nil {
+		return err
+	}
+	ks, err := w.GetAllIdentityKeys()
+	if err != nil {
+		return err
+	}
+	if len(fs) > 0 || len(es) > 0 || len(ks) > 0 {
+		return ErrWalletNotEmpty
+	}
+
+	seed := new(DBSeed)
+	seed.MnemonicSeed = mnemonic
+	if opts != nil {
+		seed.Passphrase = opts.Passphrase
+		seed.FactoidPath = opts.FactoidPath
+		seed.ECPath = opts.ECPath
+		seed.IdentityPath = opts.IdentityPath
+	}
+	return w.InsertDBSeed(seed)
+}
+
 // ExportWallet writes all the secret/publilc key pairs from a wallet and the
 // wallet seed in a pritable format.
 func ExportWallet(path string) (string, []*factom.FactoidAddress, []*factom.ECAddress, error) {
+	m, _, fs, es, err := ExportWalletWithOptions(path)
+	return m, fs, es, err
+}
+
+// ExportWalletWithOptions exports the wallet like ExportWallet, with the
+// passphrase and derivation paths needed to derive the same addresses from the
+// seed.
+func ExportWalletWithOptions(path string) (string, *factom.DerivationOptions, []*factom.FactoidAddress, []*factom.ECAddress, error) {
 	// check if the file exists
 	_, err := os.Stat(path)
 	if err != nil {
-		return "", nil, nil, err
+		return "", nil, nil, nil, err
 	}
 
 	w, err := NewOrOpenBoltDBWallet(path)
 	if err != nil {
-		return "", nil, nil, err
+		return "", nil, nil, nil, err
 	}
 
+	return exportWallet(w)
+}
+
+func exportWallet(w *Wallet) (string, *factom.DerivationOptions, []*factom.FactoidAddress, []*factom.ECAddress, error) {
 	m, err := w.GetSeed()
 	if err != nil {
-		return "", nil, nil, err
+		return "", nil, nil, nil, err
+	}
+	opts, err := w.GetDerivationOptions()
+	if err != nil {
+		return "", nil, nil, nil, err
 	}
 	fs, es, err := w.GetAllAddresses()
 	if err != nil {
-		return "", nil, nil, err
+		return "", nil, nil, nil, err
 	}
-	return m, fs, es, nil
+	return m, opts, fs, es, nil
 }
@@ -5,9 +5,10 @@
 package wallet_test
 
 import (
+	"os"
 	"testing"
 
-	//"github.com/FactomProject/factom"
+	"github.com/FactomProject/factom"
 	. "github.com/FactomProject/factom/wallet"
 )
 
@@ -23,3 +24,58 @@ func TestImportWithSpaces(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestExportWalletOptions(t *testing.T) {
+	m := "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow"
+	path := os.TempDir() + "/test_wallet-export"
+	os.Remove(path)
+	defer os.Remove(path)
+
+	fpath, err := factom.ParseDerivationPath("m/44'/131'/1'/0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &factom.DerivationOptions{Passphrase: "secret", FactoidPath: fpath}
+
+	w, err := ImportWalletFromMnemonicWithOptions(m, path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := w.GenerateFCTAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	seed, exported, fs, _, err := ExportWalletWithOptions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seed != m || len(fs) != 1 || fs[0].String() != f.String() {
+		t.Errorf("unexpected export %s %v", seed, fs)
+	}
+	if exported.Passphrase != "secret" || exported.FactoidPath.String() != fpath.String() ||
+		exported.ECPath.String() != factom.NewECPath(0).String() {
+		t.Errorf("unexpected derivation options %+v", exported)
+	}
+
+	// the exported seed and options derive the same addresses
+	w2, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w2.Close()
+	if err := w2.RestoreSeed(seed, exported); err != nil {
+		t.Fatal(err)
+	}
+	f2, err := w2.GenerateFCTAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f2.String() != f.String() {
+		t.Errorf("restored seed derived %s, expected %s", f2, f)
+	}
+	if err := w2.RestoreSeed(seed, nil); err != ErrWalletNotEmpty {
+		t.Errorf("expected %v, found %v", ErrWalletNotEmpty, err)
+	}
+}
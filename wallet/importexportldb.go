@@ -47,24 +47,24 @@ func ImportLDBWalletFromMnemonic(mnemonic, path string) (*Wallet, error) {
 // ExportLDBWallet writes all the secret/publilc key pairs from a wallet and the
 // wallet seed in a pritable format.
 func ExportLDBWallet(path string) (string, []*factom.FactoidAddress, []*factom.ECAddress, error) {
+	m, _, fs, es, err := ExportLDBWalletWithOptions(path)
+	return m, fs, es, err
+}
+
+// ExportLDBWalletWithOptions exports the wallet like ExportLDBWallet, with the
+// passphrase and derivation paths needed to derive the same addresses from the
+// seed.
+func ExportLDBWalletWithOptions(path string) (string, *factom.DerivationOptions, []*factom.FactoidAddress, []*factom.ECAddress, error) {
 	// check if the file exists
 	_, err := os.Stat(path)
 	if err != nil {
-		return "", nil, nil, err
+		return "", nil, nil, nil, err
 	}
 
 	w, err := NewOrOpenLevelDBWallet(path)
 	if err != nil {
-		return "", nil, nil, err
+		return "", nil, nil, nil, err
 	}
 
-	m, err := w.GetSeed()
-	if err != nil {
-		return "", nil, nil, err
-	}
-	fs, es, err := w.GetAllAddresses()
-	if err != nil {
-		return "", nil, nil, err
-	}
-	return m, fs, es, nil
+	return exportWallet(w)
 }
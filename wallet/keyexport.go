@@ -0,0 +1,63 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"github.com/FactomProject/factom"
+)
+
+// ExportEncryptedKey returns the secret key of a Factoid address, an Entry
+// Credit address, or an Identity Key in the wallet, encrypted with password.
+func (w *Wallet) ExportEncryptedKey(pub, password string) (string, error) {
+	switch {
+	case factom.AddressStringType(pub) == factom.FactoidPub:
+		f, err := w.GetFCTAddress(pub)
+		if err != nil {
+			return "", err
+		}
+		return f.EncryptedSecString(password)
+	case factom.AddressStringType(pub) == factom.ECPub:
+		e, err := w.GetECAddress(pub)
+		if err != nil {
+			return "", err
+		}
+		return e.EncryptedSecString(password)
+	case factom.IdentityKeyStringType(pub) == factom.IDPub:
+		k, err := w.GetIdentityKey(pub)
+		if err != nil {
+			return "", err
+		}
+		return k.EncryptedSecString(password)
+	}
+
+	return "", ErrNoSuchAddress
+}
+
+// ImportEncryptedKey decrypts an encrypted key string with password and adds
+// the key to the wallet. It returns the public address or Identity Key.
+func (w *Wallet) ImportEncryptedKey(secret, password string) (string, error) {
+	switch factom.EncryptedKeyStringType(secret) {
+	case factom.FactoidSecEncrypted:
+		f, err := factom.GetEncryptedFactoidAddress(secret, password)
+		if err != nil {
+			return "", err
+		}
+		return f.String(), w.InsertFCTAddress(f)
+	case factom.ECSecEncrypted:
+		e, err := factom.GetEncryptedECAddress(secret, password)
+		if err != nil {
+			return "", err
+		}
+		return e.String(), w.InsertECAddress(e)
+	case factom.IDSecEncrypted:
+		k, err := factom.GetEncryptedIdentityKey(secret, password)
+		if err != nil {
+			return "", err
+		}
+		return k.String(), w.InsertIdentityKey(k)
+	}
+
+	return "", ErrInvalidEncryptedKey
+}
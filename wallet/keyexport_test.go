@@ -0,0 +1,74 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factom"
+	. "github.com/FactomProject/factom/wallet"
+)
+
+func TestEncryptedKeyExportImport(t *testing.T) {
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+	w2, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w2.Close()
+
+	fa, err := w1.GenerateFCTAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec, err := w1.GenerateECAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := w1.GenerateIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pub := range []string{fa.String(), ec.String(), id.String()} {
+		sec, err := w1.ExportEncryptedKey(pub, "password")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if factom.EncryptedKeyStringType(sec) == factom.InvalidEncryptedKey {
+			t.Errorf("invalid encrypted key %s", sec)
+		}
+
+		if _, err := w2.ImportEncryptedKey(sec, "wrong"); err != factom.ErrKeyPassword {
+			t.Errorf("expected ErrKeyPassword, got %v", err)
+		}
+		p, err := w2.ImportEncryptedKey(sec, "password")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != pub {
+			t.Errorf("imported %s, expected %s", p, pub)
+		}
+	}
+
+	if f2, err := w2.GetFCTAddress(fa.String()); err != nil {
+		t.Error(err)
+	} else if f2.SecString() != fa.SecString() {
+		t.Error("imported Factoid key does not match")
+	}
+	if k2, err := w2.GetIdentityKey(id.String()); err != nil {
+		t.Error(err)
+	} else if k2.SecString() != id.SecString() {
+		t.Error("imported identity key does not match")
+	}
+
+	if _, err := w2.ImportEncryptedKey(fa.SecString(), "password"); err != ErrInvalidEncryptedKey {
+		t.Errorf("expected ErrInvalidEncryptedKey, got %v", err)
+	}
+}
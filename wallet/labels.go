@@ -0,0 +1,98 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factom"
+)
+
+// SetLabel sets the label, tags and notes for an address or identity key held
+// in the Wallet, including watch-only and multisig addresses.
+func (w *Wallet) SetLabel(address, label string, tags []string, notes string) (*factom.AddressLabel, error) {
+	l, err := factom.NewAddressLabel(address, label, tags, notes)
+	if err != nil {
+		return nil, err
+	}
+	if !w.HasAddress(address) {
+		return nil, fmt.Errorf("wallet: %s is not in the wallet", address)
+	}
+	if err := w.InsertLabel(l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// AddContact adds an external address or identity key to the Wallet address
+// book.
+func (w *Wallet) AddContact(address, label string, tags []string, notes string) (*factom.AddressLabel, error) {
+	l, err := factom.NewAddressLabel(address, label, tags, notes)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.InsertContact(l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// RestoreLabels restores the labels and address book entries from a wallet
+// backup. Labels are only restored for addresses held in the Wallet, so the
+// keys should be imported first. The labels that could not be restored are
+// returned.
+func (w *Wallet) RestoreLabels(labels, contacts []*factom.AddressLabel) ([]*factom.AddressLabel, error) {
+	for _, c := range contacts {
+		if _, err := w.AddContact(c.Address, c.Label, c.Tags, c.Notes); err != nil {
+			return nil, err
+		}
+	}
+
+	skipped := make([]*factom.AddressLabel, 0)
+	for _, l := range labels {
+		if !w.HasAddress(l.Address) {
+			skipped = append(skipped, l)
+			continue
+		}
+		if _, err := w.SetLabel(l.Address, l.Label, l.Tags, l.Notes); err != nil {
+			return nil, err
+		}
+	}
+	return skipped, nil
+}
+
+// HasAddress returns true if the public address or identity key is held in the
+// Wallet in any form.
+func (w *Wallet) HasAddress(address string) bool {
+	if _, err := w.GetFCTAddress(address); err == nil {
+		return true
+	}
+	if _, err := w.GetECAddress(address); err == nil {
+		return true
+	}
+	if _, err := w.GetIdentityKey(address); err == nil {
+		return true
+	}
+	if _, err := w.GetMultisigAddress(address); err == nil {
+		return true
+	}
+	return w.IsWatchOnly(address)
+}
+
+// LookupLabel returns the label for an address; from the wallet labels, the
+// watch-only addresses, or the address book, in that order. It returns an
+// empty string if the address has no label.
+func (w *Wallet) LookupLabel(address string) string {
+	if l, err := w.GetLabel(address); err == nil {
+		return l.Label
+	}
+	if a, err := w.GetWatchOnlyAddress(address); err == nil && a.Label != "" {
+		return a.Label
+	}
+	if l, err := w.GetContact(address); err == nil {
+		return l.Label
+	}
+	return ""
+}
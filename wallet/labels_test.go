@@ -0,0 +1,115 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factom"
+	. "github.com/FactomProject/factom/wallet"
+)
+
+func TestLabels(t *testing.T) {
+	zSec := "Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj"
+	external := "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"
+
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+
+	f, err := factom.GetFactoidAddress(zSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.InsertFCTAddress(f); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w1.SetLabel(f.String(), "Marketing", []string{"dept"}, "monthly budget"); err != nil {
+		t.Error(err)
+	}
+	if _, err := w1.SetLabel(external, "Not ours", nil, ""); err == nil {
+		t.Error("expected error labeling an address that is not in the wallet")
+	}
+	if _, err := w1.AddContact(external, "Supplier", []string{"vendor"}, ""); err != nil {
+		t.Error(err)
+	}
+
+	if l := w1.LookupLabel(f.String()); l != "Marketing" {
+		t.Errorf("expected label Marketing, got %q", l)
+	}
+	if l := w1.LookupLabel(external); l != "Supplier" {
+		t.Errorf("expected label Supplier, got %q", l)
+	}
+
+	ls, err := w1.GetAllLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ls) != 1 || ls[0].Notes != "monthly budget" {
+		t.Errorf("unexpected labels %v", ls)
+	}
+
+	if err := w1.RemoveContact(external); err != nil {
+		t.Error(err)
+	}
+	if l := w1.LookupLabel(external); l != "" {
+		t.Errorf("contact was not removed, got label %q", l)
+	}
+	if err := w1.RemoveContact(external); err != ErrNoSuchLabel {
+		t.Errorf("expected ErrNoSuchLabel, got %v", err)
+	}
+
+	// the label goes with the address
+	if err := w1.RemoveAddress(f.String()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w1.GetLabel(f.String()); err != ErrNoSuchLabel {
+		t.Errorf("expected ErrNoSuchLabel, got %v", err)
+	}
+}
+
+func TestRestoreLabels(t *testing.T) {
+	zSec := "Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj"
+	external := "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"
+
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+
+	f, err := factom.GetFactoidAddress(zSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.InsertFCTAddress(f); err != nil {
+		t.Fatal(err)
+	}
+
+	labels := []*factom.AddressLabel{
+		{Address: f.String(), Label: "Marketing", Tags: []string{"dept"}},
+		{Address: external, Label: "Not ours"},
+	}
+	contacts := []*factom.AddressLabel{
+		{Address: external, Label: "Supplier", Notes: "net 30"},
+	}
+	skipped, err := w1.RestoreLabels(labels, contacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 || skipped[0].Address != external {
+		t.Errorf("expected the label of the external address to be skipped, got %v", skipped)
+	}
+
+	if l, err := w1.GetLabel(f.String()); err != nil || l.Label != "Marketing" || !l.HasTag("dept") {
+		t.Errorf("label was not restored: %v %v", l, err)
+	}
+	if l, err := w1.GetContact(external); err != nil || l.Notes != "net 30" {
+		t.Errorf("contact was not restored: %v %v", l, err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/FactomProject/factom"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/goleveldb/leveldb"
+)
+
+// ImportMultisigAddress creates an m of n multisig Factoid address from the
+// public Factoid addresses of its members and stores it in the Wallet. The
+// member keys do not need to be in the Wallet.
+func (w *Wallet) ImportMultisigAddress(m int, members ...string) (*factom.RCD2, error) {
+	r, err := factom.NewRCD2(m, members...)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.InsertMultisigAddress(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetMultisigSignatures returns the member signatures collected so far for a
+// multisig input to a tmp transaction.
+func (w *Wallet) GetMultisigSignatures(name, address string) ([]*factom.MultisigSignature, error) {
+	if !w.TransactionExists(name) {
+		return nil, ErrTXNotExists
+	}
+
+	w.txlock.Lock()
+	defer w.txlock.Unlock()
+
+	sigs := w.multisigs[name][address]
+	ret := make([]*factom.MultisigSignature, len(sigs))
+	copy(ret, sigs)
+	return ret, nil
+}
+
+// AddMultisigSignatures adds member signatures made by other parties to a
+// multisig input of a tmp transaction. Once enough signatures have been
+// collected the signature block for the input is set.
+func (w *Wallet) AddMultisigSignatures(name, address string, sigs ...*factom.MultisigSignature) error {
+	tx, err := w.GetTransaction(name)
+	if err != nil {
+		return err
+	}
+
+	r, err := w.GetMultisigAddress(address)
+	if err != nil {
+		return err
+	}
+
+	i, err := inputIndex(tx, address)
+	if err != nil {
+		return err
+	}
+
+	data, err := tx.MarshalBinarySig()
+	if err != nil {
+		return err
+	}
+	for _, sig := range sigs {
+		if _, err := r.VerifySignature(data, sig); err != nil {
+			return err
+		}
+	}
+
+	return w.collectMultisig(name, tx, i, r, data, sigs)
+}
+
+// signMultisig signs the multisig input i of the transaction with every member
+// key held by the Wallet or one of its external Signers.
+func (w *Wallet) signMultisig(name string, tx *factoid.Transaction, i int, r *factom.RCD2, data []byte) error {
+	sigs := make([]*factom.MultisigSignature, 0)
+	for _, m := range r.MemberStrings() {
+		f, err := w.GetFCTSigner(m)
+		if err == ErrNoSuchAddress || err == ErrWatchOnly || err == leveldb.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		sig, err := factom.SignMultisigWithSigner(f, data)
+		if err != nil {
+			return err
+		}
+		sigs = append(sigs, sig)
+	}
+
+	return w.collectMultisig(name, tx, i, r, data, sigs)
+}
+
+// collectMultisig stores new member signatures for input i of the transaction
+// and sets its signature block once there are enough of them. Signatures that
+// no longer match the transaction data are dropped.
+func (w *Wallet) collectMultisig(name string, tx *factoid.Transaction, i int, r *factom.RCD2, data []byte, sigs []*factom.MultisigSignature) error {
+	w.txlock.Lock()
+	defer w.txlock.Unlock()
+
+	if w.multisigs == nil {
+		w.multisigs = make(map[string]map[string][]*factom.MultisigSignature)
+	}
+	if w.multisigs[name] == nil {
+		w.multisigs[name] = make(map[string][]*factom.MultisigSignature)
+	}
+	address := r.String()
+
+	// keep one valid signature per member, ordered by member position
+	byMember := make(map[int]*factom.MultisigSignature)
+	for _, sig := range append(w.multisigs[name][address], sigs...) {
+		if n, err := r.VerifySignature(data, sig); err == nil {
+			byMember[n] = sig
+		}
+	}
+	members := make([]int, 0, len(byMember))
+	for n := range byMember {
+		members = append(members, n)
+	}
+	sort.Ints(members)
+
+	collected := make([]*factom.MultisigSignature, len(members))
+	for j, n := range members {
+		collected[j] = byMember[n]
+	}
+	w.multisigs[name][address] = collected
+
+	if len(collected) < r.M {
+		return nil
+	}
+
+	raw := make([][]byte, r.M)
+	for j, sig := range collected[:r.M] {
+		raw[j] = sig.Sig
+	}
+	block, err := newSignatureBlock(raw...)
+	if err != nil {
+		return err
+	}
+	tx.SetSignatureBlock(i, block)
+
+	return nil
+}
+
+// multisigRCD returns the factomd RCD for a multisig address.
+func multisigRCD(r *factom.RCD2) (interfaces.IRCD, error) {
+	members := make([]interfaces.IAddress, len(r.Members))
+	for i, m := range r.Members {
+		members[i] = factoid.NewAddress(m)
+	}
+	return factoid.NewRCD_2(r.M, len(members), members)
+}
+
+func inputIndex(tx *factoid.Transaction, address string) (int, error) {
+	for i, in := range tx.GetInputs() {
+		if primitives.ConvertFctAddressToUserStr(in.GetAddress()) == address {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("%s is not an input to the transaction.", address)
+}
@@ -0,0 +1,157 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/FactomProject/factom"
+	. "github.com/FactomProject/factom/wallet"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+func TestMultisigTransaction(t *testing.T) {
+	keys := make([]*factom.FactoidAddress, 3)
+	members := make([]string, 3)
+	for i := range keys {
+		k, err := factom.MakeFactoidAddress(bytes.Repeat([]byte{byte(i + 1)}, 32))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = k
+		members[i] = k.String()
+	}
+	out := "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"
+
+	// each party holds one key of a 2 of 3 multisig address
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+	w2, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w2.Close()
+
+	if err := w1.InsertFCTAddress(keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w2.InsertFCTAddress(keys[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	ms, err := w1.ImportMultisigAddress(2, members...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w2.ImportMultisigAddress(2, members...); err != nil {
+		t.Fatal(err)
+	}
+	if all, err := w1.GetAllMultisigAddresses(); err != nil {
+		t.Error(err)
+	} else if len(all) != 1 || all[0].String() != ms.String() {
+		t.Errorf("multisig address was not stored: %v", all)
+	}
+
+	// the first party builds and signs the transaction
+	if err := w1.NewTransaction("tx"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.AddInput("tx", ms.String(), 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.AddOutput("tx", out, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.SignTransaction("tx", true); err != nil {
+		t.Fatal(err)
+	}
+	sigs, err := w1.GetMultisigSignatures("tx", ms.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 1 {
+		t.Fatalf("expected 1 partial signature, got %d", len(sigs))
+	}
+
+	// the second party imports the transaction, signs it, and adds the
+	// signature from the first party
+	tx, err := w1.GetTransaction("tx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the input address and the address factomd derives from the RCD are both
+	// the multisig address
+	if a := primitives.ConvertFctAddressToUserStr(tx.GetInputs()[0].GetAddress()); a != ms.String() {
+		t.Errorf("expected input %s, found %s", ms.String(), a)
+	}
+	rcdAdr, err := tx.GetRCDs()[0].GetAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rcdAdr.Bytes(), ms.Hash()) {
+		t.Errorf("expected RCD address %x, found %x", ms.Hash(), rcdAdr.Bytes())
+	}
+	// one of two signatures does not satisfy factomd
+	if err := tx.ValidateSignatures(); err == nil {
+		t.Error("factomd accepted a multisig input with too few signatures")
+	}
+	p, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w2.ImportComposedTransaction("tx", hex.EncodeToString(p)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w2.SignTransaction("tx", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := w2.AddMultisigSignatures("tx", ms.String(), sigs...); err != nil {
+		t.Error(err)
+	}
+	sigs, err = w2.GetMultisigSignatures("tx", ms.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 2 {
+		t.Errorf("expected 2 signatures, got %d", len(sigs))
+	}
+
+	// the fully signed transaction decodes and validates in factomd
+	signed, err := w2.GetTransaction("tx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = signed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(factoid.Transaction)
+	if err := decoded.UnmarshalBinary(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.ValidateSignatures(); err != nil {
+		t.Errorf("factomd rejected the multisig signatures: %v", err)
+	}
+
+	// a signature from a key outside of the multisig is rejected
+	bad, err := factom.MakeFactoidAddress(bytes.Repeat([]byte{9}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := tx.MarshalBinarySig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w2.AddMultisigSignatures("tx", ms.String(), factom.SignMultisig(bad, data)); err == nil {
+		t.Error("expected error for a non member signature")
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/FactomProject/factom"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// DefaultGapLimit is the number of consecutive unused addresses after which
+// the recovery scan stops.
+const DefaultGapLimit = 20
+
+// RecoveryResult lists the addresses found by a recovery scan.
+type RecoveryResult struct {
+	FCTAddresses            []*factom.FactoidAddress
+	ECAddresses             []*factom.ECAddress
+	NextFactoidAddressIndex uint32
+	NextECAddressIndex      uint32
+}
+
+// RecoverWalletFromMnemonic creates a new wallet from a bip-0039 Mnemonic seed
+// and restores the addresses that have been used on the blockchain. opts may
+// be nil to use the default derivation. If the recovery fails the new wallet
+// is closed and removed, so that it can be tried again.
+func RecoverWalletFromMnemonic(mnemonic, path string, opts *factom.DerivationOptions, gapLimit uint32) (*Wallet, *RecoveryResult, error) {
+	w, err := ImportWalletFromMnemonicWithOptions(mnemonic, path, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := w.RecoverAddresses(gapLimit)
+	if err != nil {
+		w.Close()
+		if rerr := os.Remove(path); rerr != nil && !os.IsNotExist(rerr) {
+			return nil, nil, fmt.Errorf("%v; removing wallet %s: %v", err, path, rerr)
+		}
+		return nil, nil, err
+	}
+
+	return w, r, nil
+}
+
+// RecoverAddresses derives Factoid and Entry Credit addresses from the Wallet
+// Seed and stores every address that has been used, until gapLimit
+// consecutive addresses are found unused. An address is used if it has a
+// balance or appears in the transaction history, so addresses that have been
+// emptied are found too. The next address indexes of the seed are moved past
+// the last used address.
+//
+// The transaction history is read from the Wallet transaction database. A
+// Wallet without one downloads every Factoid block from factomd and holds the
+// whole transaction history in memory for the scan, which is slow and memory
+// hungry on mainnet; open the Wallet with a transaction database to avoid it.
+func (w *Wallet) RecoverAddresses(gapLimit uint32) (*RecoveryResult, error) {
+	if gapLimit == 0 {
+		gapLimit = DefaultGapLimit
+	}
+
+	seed, err := w.GetDBSeed()
+	if err != nil {
+		return nil, err
+	}
+	if seed == nil {
+		return nil, fmt.Errorf("dbSeed not present in DB")
+	}
+
+	history, err := w.txdbAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(RecoveryResult)
+
+	// scan the Factoid addresses with a scratch copy of the seed
+	scan := new(DBSeed)
+	scan.DBSeedBase = seed.DBSeedBase
+	scan.NextFactoidAddressIndex = 0
+	scan.NextECAddressIndex = 0
+	for gap := uint32(0); gap < gapLimit; {
+		a, err := scan.NextFCTAddress()
+		if err != nil {
+			return nil, err
+		}
+
+		used := history[a.String()]
+		if !used {
+			balance, err := factom.GetFactoidBalance(a.String())
+			if err != nil {
+				return nil, err
+			}
+			used = balance != 0
+		}
+
+		if !used {
+			gap++
+			continue
+		}
+		gap = 0
+		if err := w.InsertFCTAddress(a); err != nil {
+			return nil, err
+		}
+		r.FCTAddresses = append(r.FCTAddresses, a)
+		r.NextFactoidAddressIndex = scan.NextFactoidAddressIndex
+	}
+
+	// scan the Entry Credit addresses
+	for gap := uint32(0); gap < gapLimit; {
+		a, err := scan.NextECAddress()
+		if err != nil {
+			return nil, err
+		}
+
+		used := history[a.PubString()]
+		if !used {
+			balance, err := factom.GetECBalance(a.PubString())
+			if err != nil {
+				return nil, err
+			}
+			used = balance != 0
+		}
+
+		if !used {
+			gap++
+			continue
+		}
+		gap = 0
+		if err := w.InsertECAddress(a); err != nil {
+			return nil, err
+		}
+		r.ECAddresses = append(r.ECAddresses, a)
+		r.NextECAddressIndex = scan.NextECAddressIndex
+	}
+
+	// never move the indexes backwards; addresses may already have been
+	// generated past the last used address
+	if r.NextFactoidAddressIndex < seed.NextFactoidAddressIndex {
+		r.NextFactoidAddressIndex = seed.NextFactoidAddressIndex
+	}
+	if r.NextECAddressIndex < seed.NextECAddressIndex {
+		r.NextECAddressIndex = seed.NextECAddressIndex
+	}
+	seed.NextFactoidAddressIndex = r.NextFactoidAddressIndex
+	seed.NextECAddressIndex = r.NextECAddressIndex
+	if err := w.InsertDBSeed(seed); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// txdbAddresses returns the set of addresses that appear in the transaction
+// history. factomd has no index of transactions by address, so if the Wallet
+// has no transaction database the Factoid blocks are read from factomd into a
+// scratch database for the scan.
+func (w *Wallet) txdbAddresses() (map[string]bool, error) {
+	txdb := w.txdb
+	if txdb == nil {
+		txdb = NewTXMapDB()
+		defer txdb.Close()
+	}
+
+	adrs := make(map[string]bool)
+	txs, err := txdb.GetAllTXs()
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range txs {
+		for _, in := range tx.GetInputs() {
+			adrs[primitives.ConvertFctAddressToUserStr(in.GetAddress())] = true
+		}
+		for _, out := range tx.GetOutputs() {
+			adrs[primitives.ConvertFctAddressToUserStr(out.GetAddress())] = true
+		}
+		for _, ec := range tx.GetECOutputs() {
+			adrs[primitives.ConvertECAddressToUserStr(ec.GetAddress())] = true
+		}
+	}
+
+	return adrs, nil
+}
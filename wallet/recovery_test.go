@@ -0,0 +1,186 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/FactomProject/factom"
+	. "github.com/FactomProject/factom/wallet"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+const recoveryMnemonic = "yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow yellow"
+
+// recoveryChain serves address balances and a Factoid chain of two blocks.
+type recoveryChain struct {
+	balances map[string]int64
+	fblocks  []interfaces.IFBlock
+}
+
+func (c *recoveryChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	req, _ := factom.ParseJSON2Request(string(body))
+	p := new(struct {
+		Address string `json:"address"`
+		Height  int    `json:"height"`
+	})
+	json.Unmarshal(req.Params, p)
+
+	raw := func(fb interfaces.IFBlock) string {
+		data, _ := fb.MarshalBinary()
+		return hex.EncodeToString(data)
+	}
+	head := c.fblocks[len(c.fblocks)-1]
+
+	var result string
+	switch req.Method {
+	case "directory-block-head":
+		result = fmt.Sprintf(`{"keymr": %q}`, factom.ZeroHash)
+	case "directory-block":
+		result = fmt.Sprintf(`{"entryblocklist": [{"chainid": "000000000000000000000000000000000000000000000000000000000000000f", "keymr": %q}]}`, head.GetKeyMR().String())
+	case "raw-data":
+		result = fmt.Sprintf(`{"data": %q}`, raw(head))
+	case "fblock-by-height":
+		result = fmt.Sprintf(`{"rawdata": %q}`, raw(c.fblocks[p.Height]))
+	default:
+		result = fmt.Sprintf(`{"balance": %d}`, c.balances[p.Address])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"jsonrpc": "2.0", "id": 0, "result": %s}`, result)
+}
+
+// newRecoveryChain creates a Factoid chain where the first Factoid address of
+// the recovery mnemonic was emptied.
+func newRecoveryChain(t *testing.T, balances map[string]int64) *recoveryChain {
+	w0, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w0.Close()
+	seed, err := w0.GetDBSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed.MnemonicSeed = recoveryMnemonic
+	if err := w0.InsertDBSeed(seed); err != nil {
+		t.Fatal(err)
+	}
+	f, err := w0.GenerateFCTAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w0.NewTransaction("tx"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w0.AddInput("tx", f.String(), 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := w0.AddOutput("tx", "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q", 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := w0.SignTransaction("tx", true); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := w0.GetTransaction("tx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coinbase := new(factoid.Transaction)
+	coinbase.SetTimestamp(primitives.NewTimestampNow())
+
+	fb0 := factoid.NewFBlock(nil)
+	fb1 := factoid.NewFBlock(fb0)
+	if err := fb1.AddTransaction(coinbase); err != nil {
+		t.Fatal(err)
+	}
+	if err := fb1.AddTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	return &recoveryChain{balances: balances, fblocks: []interfaces.IFBlock{fb0, fb1}}
+}
+
+func TestRecoverAddresses(t *testing.T) {
+	// the second Factoid address and first Entry Credit address of the seed
+	// have balances, and the first Factoid address only appears in the
+	// transaction history
+	ts := httptest.NewServer(newRecoveryChain(t, map[string]int64{
+		"FA3heCmxKCk1tCCfiAMDmX8Ctg6XTQjRRaJrF5Jagc9rbo7wqQLV": 100,
+		"EC2KnJQN86MYq4pQyeSGTHSiVdkhRCPXS3udzD4im6BXRBjZFMmR": 10,
+	}))
+	defer ts.Close()
+	factom.SetFactomdServer(ts.URL[7:])
+
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+
+	seed, err := w1.GetDBSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed.MnemonicSeed = recoveryMnemonic
+	if err := w1.InsertDBSeed(seed); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := w1.RecoverAddresses(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.FCTAddresses) != 2 ||
+		r.FCTAddresses[0].String() != "FA22de5NSG2FA2HmMaD4h8qSAZAJyztmmnwgLPghCQKoSekwYYct" ||
+		r.FCTAddresses[1].String() != "FA3heCmxKCk1tCCfiAMDmX8Ctg6XTQjRRaJrF5Jagc9rbo7wqQLV" {
+		t.Errorf("unexpected Factoid addresses %v", r.FCTAddresses)
+	}
+	if len(r.ECAddresses) != 1 {
+		t.Errorf("unexpected Entry Credit addresses %v", r.ECAddresses)
+	}
+	if r.NextFactoidAddressIndex != 2 || r.NextECAddressIndex != 1 {
+		t.Errorf("wrong next indexes %d %d", r.NextFactoidAddressIndex, r.NextECAddressIndex)
+	}
+
+	// the next generated address follows the last used address
+	f, err := w1.GenerateFCTAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.String() == "FA3heCmxKCk1tCCfiAMDmX8Ctg6XTQjRRaJrF5Jagc9rbo7wqQLV" || f.String() == "FA22de5NSG2FA2HmMaD4h8qSAZAJyztmmnwgLPghCQKoSekwYYct" {
+		t.Errorf("generated a used address %s", f)
+	}
+}
+
+func TestRecoverWalletFromMnemonicFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	factom.SetFactomdServer(ts.URL[7:])
+
+	path := os.TempDir() + "/test_wallet-recovery"
+	os.Remove(path)
+	if _, _, err := RecoverWalletFromMnemonic(recoveryMnemonic, path, nil, 3); err == nil {
+		t.Fatal("expected an error recovering without factomd")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the failed wallet to be removed, found %v", err)
+		os.Remove(path)
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/FactomProject/factom"
+)
+
+// RetiredKeyTag is the label tag given to Identity Keys in the Wallet that were
+// replaced by a key rotation.
+const RetiredKeyTag = "retired"
+
+// KeyRotation is a planned rotation of the Identity Keys of an identity
+// controlled by the Wallet. The key replacements are ordered from the lowest
+// priority level to the highest, and are all signed by SignerKey, the highest
+// priority key the Wallet holds. If SignerKey is itself rotated, it is
+// replaced last, once the other replacements are confirmed, since they would
+// not be valid after it is retired.
+type KeyRotation struct {
+	ChainID      string
+	SignerKey    string
+	Replacements []*KeyReplacement
+
+	// PollInterval is the time between checks for confirmation of the
+	// published replacements, and Timeout the longest time to wait for them
+	PollInterval time.Duration
+	Timeout      time.Duration
+}
+
+// KeyReplacement is a single key replacement of a KeyRotation. Committed is
+// set once the entry commit is accepted, and EntryHash once the entry is
+// revealed.
+type KeyReplacement struct {
+	Level     int
+	OldKey    string
+	NewKey    string
+	Entry     *factom.Entry
+	Committed bool
+	EntryHash string
+	Confirmed bool
+}
+
+// PlanKeyRotation refreshes an identity controlled by the Wallet and plans the
+// rotation of the keys at the levels, or of every level if none are given. A
+// new Identity Key is generated and saved in the Wallet for each level before
+// anything is published, so that the identity stays recoverable if the
+// rotation is interrupted.
+func (w *Wallet) PlanKeyRotation(chainID string, levels ...int) (*KeyRotation, error) {
+	i, err := w.RefreshIdentity(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(levels) == 0 {
+		for _, k := range i.Keys {
+			levels = append(levels, k.Level)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(levels)))
+	for n, level := range levels {
+		if level < 0 || level >= len(i.Keys) {
+			return nil, fmt.Errorf("wallet: identity %s has no key at level %d", chainID, level)
+		}
+		if n > 0 && level == levels[n-1] {
+			return nil, fmt.Errorf("wallet: level %d is rotated more than once", level)
+		}
+	}
+
+	// a key can only be replaced by a key of the same or a higher priority
+	signerKey := i.SignerKey(levels[len(levels)-1])
+	if signerKey == "" {
+		return nil, fmt.Errorf("wallet: Wallet does not hold a key of identity %s at level %d or higher", chainID, levels[len(levels)-1])
+	}
+	signer, err := w.GetIdentitySigner(signerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(KeyRotation)
+	r.ChainID = chainID
+	r.SignerKey = signerKey
+	r.PollInterval = 10 * time.Second
+	r.Timeout = 30 * time.Minute
+	for _, level := range levels {
+		k, err := w.GenerateIdentityKey()
+		if err != nil {
+			return nil, err
+		}
+		oldKey := i.Keys[level].Key
+		e, err := factom.NewIdentityKeyReplacementEntryWithSigner(chainID, oldKey, k.PubString(), signer)
+		if err != nil {
+			return nil, err
+		}
+		r.Replacements = append(r.Replacements, &KeyReplacement{
+			Level:  level,
+			OldKey: oldKey,
+			NewKey: k.PubString(),
+			Entry:  e,
+		})
+	}
+	return r, nil
+}
+
+// RotateIdentityKeys plans and executes the rotation of the keys of an identity
+// controlled by the Wallet, paying for the entries with the Entry Credit
+// address ecpub. See PlanKeyRotation and ExecuteKeyRotation.
+func (w *Wallet) RotateIdentityKeys(chainID, ecpub string, levels ...int) (*KeyRotation, error) {
+	r, err := w.PlanKeyRotation(chainID, levels...)
+	if err != nil {
+		return nil, err
+	}
+	return r, w.ExecuteKeyRotation(r, ecpub)
+}
+
+// ExecuteKeyRotation publishes the key replacements of the rotation, paid for
+// by the Entry Credit address ecpub, and waits for them to be confirmed. It
+// then checks the active keys of the identity with
+// factom.GetActiveIdentityKeys and tags the retired keys in the Wallet with
+// RetiredKeyTag.
+//
+// Replacements that are already published or confirmed are not published
+// again, so a rotation that failed or timed out can be resumed by executing it
+// again. A replacement that was committed but not revealed is only revealed,
+// so its Entry Credits are not paid twice. factomd drops commits that are not
+// revealed within an hour, and the reveal then fails.
+func (w *Wallet) ExecuteKeyRotation(r *KeyRotation, ecpub string) error {
+	ec, err := w.GetECSigner(ecpub)
+	if err != nil {
+		return err
+	}
+
+	var first, last []*KeyReplacement
+	for _, rep := range r.Replacements {
+		if rep.OldKey == r.SignerKey {
+			last = append(last, rep)
+		} else {
+			first = append(first, rep)
+		}
+	}
+	for _, reps := range [][]*KeyReplacement{first, last} {
+		if err := publishKeyReplacements(reps, ec); err != nil {
+			return err
+		}
+		if err := r.waitForConfirmation(reps); err != nil {
+			return err
+		}
+	}
+
+	keys, _, err := factom.GetActiveIdentityKeys(r.ChainID)
+	if err != nil {
+		return err
+	}
+	for _, rep := range r.Replacements {
+		if rep.Level >= len(keys) || keys[rep.Level] != rep.NewKey {
+			return fmt.Errorf("wallet: key %s was not activated at level %d of identity %s", rep.NewKey, rep.Level, r.ChainID)
+		}
+	}
+
+	for _, rep := range r.Replacements {
+		if err := w.retireIdentityKey(rep.OldKey, rep.NewKey); err != nil {
+			return err
+		}
+	}
+	_, err = w.RefreshIdentity(r.ChainID)
+	return err
+}
+
+func publishKeyReplacements(reps []*KeyReplacement, ec factom.Signer) error {
+	for _, rep := range reps {
+		if rep.EntryHash != "" {
+			continue
+		}
+		if !rep.Committed {
+			if _, err := factom.CommitEntryWithSigner(rep.Entry, ec); err != nil {
+				return err
+			}
+			rep.Committed = true
+		}
+		hash, err := factom.RevealEntry(rep.Entry)
+		if err != nil {
+			return err
+		}
+		rep.EntryHash = hash
+	}
+	return nil
+}
+
+// waitForConfirmation polls factomd until the replacements are confirmed in a
+// Directory Block.
+func (r *KeyRotation) waitForConfirmation(reps []*KeyReplacement) error {
+	deadline := time.Now().Add(r.Timeout)
+	for {
+		pending := 0
+		for _, rep := range reps {
+			if rep.Confirmed {
+				continue
+			}
+			status, err := factom.EntryRevealACK(rep.EntryHash, "", r.ChainID)
+			if err != nil {
+				return err
+			}
+			if status.EntryData.Status == "DBlockConfirmed" {
+				rep.Confirmed = true
+			} else {
+				pending++
+			}
+		}
+		if pending == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("wallet: timed out waiting for %d key replacements of identity %s", pending, r.ChainID)
+		}
+		time.Sleep(r.PollInterval)
+	}
+}
+
+// retireIdentityKey tags a replaced Identity Key held in the Wallet with
+// RetiredKeyTag, keeping its existing label.
+func (w *Wallet) retireIdentityKey(oldKey, newKey string) error {
+	if _, err := w.GetIdentityKey(oldKey); err != nil {
+		// the key was not held by the wallet
+		return nil
+	}
+
+	label := "retired identity key"
+	var tags []string
+	var notes string
+	if l, err := w.GetLabel(oldKey); err == nil {
+		if l.HasTag(RetiredKeyTag) {
+			return nil
+		}
+		label, tags, notes = l.Label, l.Tags, l.Notes
+	}
+	if notes != "" {
+		notes += "\n"
+	}
+	notes += fmt.Sprintf("replaced by %s", newKey)
+
+	_, err := w.SetLabel(oldKey, label, append(tags, RetiredKeyTag), notes)
+	return err
+}
+
+// IsRetiredIdentityKey returns true if the Identity Key was retired by a key
+// rotation.
+func (w *Wallet) IsRetiredIdentityKey(pub string) bool {
+	l, err := w.GetLabel(pub)
+	return err == nil && l.HasTag(RetiredKeyTag)
+}
@@ -0,0 +1,131 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet_test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factom"
+	. "github.com/FactomProject/factom/wallet"
+)
+
+func TestRotateIdentityKeys(t *testing.T) {
+	var keys []*factom.IdentityKey
+	for _, s := range []string{
+		"idsec2rChEHLz3SPQQx3syQtB11pHAmxyGjux5FntnS7xqTCieHxxTc",
+		"idsec1xuUyeCCrJhsojf2wLAZqRxPzPFR8Gidd9DRRid1yGy8ncAJG3",
+		"idsec2J3nNoqdiyboCBKDGauqN9Jb33dyFSqaJKZqTs6i5FmztsTn5f",
+	} {
+		k, err := factom.GetIdentityKey(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k)
+	}
+
+	c, err := factom.NewIdentityChain([]string{"Rotation", "Test"}, []string{keys[0].PubString(), keys[1].PubString(), keys[2].PubString()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := new(identityChain)
+	chain.add(10, c.FirstEntry)
+
+	ts := httptest.NewServer(chain)
+	defer ts.Close()
+	factom.SetFactomdServer(ts.URL[7:])
+
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+
+	ec, err := w1.GetNextECAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the wallet only holds the second priority key, so it cannot rotate the
+	// first
+	if err := w1.InsertIdentityKey(keys[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w1.AddIdentity(c.ChainID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w1.PlanKeyRotation(c.ChainID); err == nil {
+		t.Error("expected an error rotating a key of higher priority than the wallet holds")
+	}
+	if _, err := w1.PlanKeyRotation(c.ChainID, 1, 1); err == nil {
+		t.Error("expected an error rotating a level twice")
+	}
+	if _, err := w1.PlanKeyRotation(c.ChainID, 3); err == nil {
+		t.Error("expected an error rotating a missing level")
+	}
+
+	if err := w1.InsertIdentityKey(keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	r, err := w1.PlanKeyRotation(c.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.SignerKey != keys[0].PubString() || len(r.Replacements) != 3 {
+		t.Fatalf("unexpected rotation %+v", r)
+	}
+	for n, rep := range r.Replacements {
+		if rep.Level != 2-n || rep.OldKey != keys[2-n].PubString() {
+			t.Errorf("replacement %d: unexpected level %d for %s", n, rep.Level, rep.OldKey)
+		}
+		if _, err := w1.GetIdentityKey(rep.NewKey); err != nil {
+			t.Errorf("replacement %d: new key is not in the wallet", n)
+		}
+		chain.expect(rep.Entry)
+	}
+
+	r.PollInterval = time.Millisecond
+	r.Timeout = time.Second
+
+	// a rotation interrupted after a commit is resumed with only the reveal
+	chain.failReveals = 1
+	if err := w1.ExecuteKeyRotation(r, ec.PubString()); err == nil {
+		t.Fatal("expected an error for a failed reveal")
+	}
+	if rep := r.Replacements[0]; !rep.Committed || rep.EntryHash != "" || chain.commits != 1 {
+		t.Fatalf("expected a committed replacement that was not revealed, found %+v after %d commits", rep, chain.commits)
+	}
+	if err := w1.ExecuteKeyRotation(r, ec.PubString()); err != nil {
+		t.Fatal(err)
+	}
+	if chain.commits != len(r.Replacements) {
+		t.Errorf("expected %d commits, found %d", len(r.Replacements), chain.commits)
+	}
+
+	active, _, err := factom.GetActiveIdentityKeys(c.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rep := range r.Replacements {
+		if !rep.Confirmed || active[rep.Level] != rep.NewKey {
+			t.Errorf("level %d was not rotated", rep.Level)
+		}
+	}
+
+	if !w1.IsRetiredIdentityKey(keys[0].PubString()) || !w1.IsRetiredIdentityKey(keys[1].PubString()) {
+		t.Error("expected the replaced keys to be retired")
+	}
+	if w1.IsRetiredIdentityKey(r.Replacements[0].NewKey) {
+		t.Error("expected the new keys to be active")
+	}
+	i, err := w1.GetWalletIdentity(c.ChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.SignerKey(0) != r.Replacements[2].NewKey {
+		t.Errorf("expected the new first priority key to sign, found %s", i.SignerKey(0))
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"errors"
+
+	"github.com/FactomProject/factom"
+)
+
+var ErrWalletNotEmpty = errors.New("wallet: Wallet already holds keys")
+
+// SplitSeed splits the Wallet Seed into n shares, any m of which restore it
+// with ImportWalletFromShares or RestoreSeedFromShares. The shares only hold
+// the mnemonic; a BIP39 passphrase or non default derivation paths from
+// GetDerivationOptions must be kept with the shares and passed when restoring.
+func (w *Wallet) SplitSeed(m, n int) ([]string, error) {
+	seed, err := w.GetSeed()
+	if err != nil {
+		return nil, err
+	}
+
+	return factom.SplitMnemonic(seed, m, n)
+}
+
+// ImportWalletFromShares creates a new wallet from the shares of a Wallet
+// Seed. opts may be nil to use the default derivation.
+func ImportWalletFromShares(path string, shares []string, opts *factom.DerivationOptions) (*Wallet, error) {
+	mnemonic, err := factom.CombineMnemonicShares(shares...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ImportWalletFromMnemonicWithOptions(mnemonic, path, opts)
+}
+
+// RestoreSeedFromShares replaces the Wallet Seed with the seed recovered from
+// shares and the options it was used with. opts may be nil to use the default
+// derivation. The wallet must not hold any addresses or Identity Keys yet; use
+// RecoverAddresses afterwards to restore the used addresses.
+func (w *Wallet) RestoreSeedFromShares(opts *factom.DerivationOptions, shares ...string) error {
+	mnemonic, err := factom.CombineMnemonicShares(shares...)
+	if err != nil {
+		return err
+	}
+
+	return w.RestoreSeed(mnemonic, opts)
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factom"
+	. "github.com/FactomProject/factom/wallet"
+)
+
+func TestSplitSeed(t *testing.T) {
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+
+	seed, err := w1.GetSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares, err := w1.SplitSeed(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shares) != 3 {
+		t.Fatalf("expected 3 shares, found %d", len(shares))
+	}
+
+	w2, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w2.Close()
+
+	if err := w2.RestoreSeedFromShares(nil, shares[0]); err == nil {
+		t.Error("expected an error for too few shares")
+	}
+	if err := w2.RestoreSeedFromShares(nil, shares[1], shares[2]); err != nil {
+		t.Fatal(err)
+	}
+	if s2, err := w2.GetSeed(); err != nil {
+		t.Error(err)
+	} else if s2 != seed {
+		t.Errorf("restored seed %q does not match %q", s2, seed)
+	}
+
+	// a wallet that already holds keys is not overwritten
+	if _, err := w2.GenerateFCTAddress(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w2.RestoreSeedFromShares(nil, shares[0], shares[2]); err != ErrWalletNotEmpty {
+		t.Errorf("expected ErrWalletNotEmpty, got %v", err)
+	}
+}
+
+func TestRestoreSeedFromSharesOptions(t *testing.T) {
+	fpath, err := factom.ParseDerivationPath("m/44'/131'/1'/0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := &factom.DerivationOptions{Passphrase: "secret", FactoidPath: fpath}
+
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+	seed, err := w1.GetSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.RestoreSeed(seed, opts); err != nil {
+		t.Fatal(err)
+	}
+	f1, err := w1.GenerateFCTAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shares, err := w1.SplitSeed(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the shares and the options derive the same addresses
+	w2, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w2.Close()
+	if err := w2.RestoreSeedFromShares(opts, shares[0], shares[1]); err != nil {
+		t.Fatal(err)
+	}
+	f2, err := w2.GenerateFCTAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f1.String() != f2.String() {
+		t.Errorf("restored address %s does not match %s", f2, f1)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"github.com/FactomProject/factom"
+	"github.com/FactomProject/goleveldb/leveldb"
+)
+
+// AddSigner registers an external Signer with the Wallet. Its key can then be
+// used as a Factoid address, an Entry Credit address, or an Identity Key
+// without the secret key being stored in the Wallet. Signers are kept in
+// memory only and must be added again when the Wallet is reopened.
+func (w *Wallet) AddSigner(s factom.Signer) {
+	w.signerlock.Lock()
+	defer w.signerlock.Unlock()
+
+	if w.signers == nil {
+		w.signers = make(map[string]factom.Signer)
+	}
+	w.signers[factom.SignerFactoidAddress(s)] = s
+	w.signers[factom.SignerECAddress(s)] = s
+	w.signers[factom.SignerIdentityKey(s)] = s
+}
+
+// RemoveSigner removes the external Signer for the public key pub.
+func (w *Wallet) RemoveSigner(pub []byte) {
+	w.signerlock.Lock()
+	defer w.signerlock.Unlock()
+
+	s := &factom.SocketSigner{Pub: pub}
+	delete(w.signers, factom.SignerFactoidAddress(s))
+	delete(w.signers, factom.SignerECAddress(s))
+	delete(w.signers, factom.SignerIdentityKey(s))
+}
+
+// GetSigners returns the external Signers registered with the Wallet.
+func (w *Wallet) GetSigners() []factom.Signer {
+	w.signerlock.RLock()
+	defer w.signerlock.RUnlock()
+
+	signers := make([]factom.Signer, 0)
+	for k, s := range w.signers {
+		if k == factom.SignerFactoidAddress(s) {
+			signers = append(signers, s)
+		}
+	}
+	return signers
+}
+
+func (w *Wallet) externalSigner(address string) (factom.Signer, bool) {
+	w.signerlock.RLock()
+	defer w.signerlock.RUnlock()
+
+	s, ok := w.signers[address]
+	return s, ok
+}
+
+// GetFCTSigner returns a Signer for the Factoid address, from the registered
+// external Signers or from the Wallet database.
+func (w *Wallet) GetFCTSigner(address string) (factom.Signer, error) {
+	if s, ok := w.externalSigner(address); ok {
+		return s, nil
+	}
+
+	a, err := w.GetFCTAddress(address)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNoSuchAddress
+	} else if err != nil {
+		return nil, err
+	}
+	return a.Signer(), nil
+}
+
+// GetECSigner returns a Signer for the Entry Credit address, from the
+// registered external Signers or from the Wallet database.
+func (w *Wallet) GetECSigner(address string) (factom.Signer, error) {
+	if s, ok := w.externalSigner(address); ok {
+		return s, nil
+	}
+
+	a, err := w.GetECAddress(address)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNoSuchAddress
+	} else if err != nil {
+		return nil, err
+	}
+	return a.Signer(), nil
+}
+
+// GetIdentitySigner returns a Signer for the Identity Key, from the
+// registered external Signers or from the Wallet database.
+func (w *Wallet) GetIdentitySigner(pub string) (factom.Signer, error) {
+	if s, ok := w.externalSigner(pub); ok {
+		return s, nil
+	}
+
+	k, err := w.GetIdentityKey(pub)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNoSuchIdentityKey
+	} else if err != nil {
+		return nil, err
+	}
+	return k.Signer(), nil
+}
+
+// SignData signs data with the key of signer, a public Factoid address, Entry
+// Credit address, or Identity Key held by the Wallet. See factom.MessageHash
+// for the signed format.
+func (w *Wallet) SignData(signer string, data []byte) (*factom.MessageSignature, error) {
+	var s factom.Signer
+	var err error
+	switch {
+	case factom.AddressStringType(signer) == factom.FactoidPub:
+		s, err = w.GetFCTSigner(signer)
+	case factom.AddressStringType(signer) == factom.ECPub:
+		s, err = w.GetECSigner(signer)
+	case factom.IdentityKeyStringType(signer) == factom.IDPub:
+		s, err = w.GetIdentitySigner(signer)
+	default:
+		return nil, ErrNoSuchAddress
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return factom.SignMessageWithSigner(s, data)
+}
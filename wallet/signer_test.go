@@ -0,0 +1,106 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factom"
+	. "github.com/FactomProject/factom/wallet"
+)
+
+func TestSignTransactionExternalSigner(t *testing.T) {
+	zSec := "Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj"
+	out := "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"
+
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+
+	fa, err := factom.GetFactoidAddress(zSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := factom.NewKeySigner(fa.SecBytes()[:32])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the wallet db does not hold the key
+	if _, err := w1.GetFCTSigner(fa.String()); err == nil {
+		t.Error("expected an error for an unknown address")
+	}
+
+	w1.AddSigner(s)
+	if len(w1.GetSigners()) != 1 {
+		t.Errorf("expected 1 signer, found %d", len(w1.GetSigners()))
+	}
+	if _, err := w1.GetECSigner(factom.SignerECAddress(s)); err != nil {
+		t.Error(err)
+	}
+	if _, err := w1.GetIdentitySigner(factom.SignerIdentityKey(s)); err != nil {
+		t.Error(err)
+	}
+
+	if err := w1.NewTransaction("tx"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.AddInput("tx", fa.String(), 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.AddOutput("tx", out, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.SignTransaction("tx", true); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := w1.GetTransaction("tx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.ValidateSignatures(); err != nil {
+		t.Error(err)
+	}
+
+	w1.RemoveSigner(s.PubBytes())
+	if _, err := w1.GetFCTSigner(fa.String()); err == nil {
+		t.Error("expected an error after removing the signer")
+	}
+}
+
+func TestSignData(t *testing.T) {
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+
+	fa, err := w1.GenerateFCTAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := w1.GenerateIdentityKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("I control this address")
+
+	for _, signer := range []string{fa.String(), id.String()} {
+		sig, err := w1.SignData(signer, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := factom.VerifyMessage(signer, data, sig); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := w1.SignData(fa.SecString(), data); err != ErrNoSuchAddress {
+		t.Errorf("expected ErrNoSuchAddress, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@
 package wallet
 
 import (
+	"bytes"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -13,18 +14,23 @@ import (
 	"github.com/FactomProject/btcutil/base58"
 	"github.com/FactomProject/factom"
 	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/goleveldb/leveldb"
 )
 
 var (
-	ErrFeeTooLow         = errors.New("wallet: Insufficient Fee")
-	ErrNoSuchAddress     = errors.New("wallet: No such address")
-	ErrNoSuchIdentityKey = errors.New("wallet: No such identity key")
-	ErrTXExists          = errors.New("wallet: Transaction name already exists")
-	ErrTXNotExists       = errors.New("wallet: Transaction name was not found")
-	ErrTXNoInputs        = errors.New("wallet: Transaction has no inputs")
-	ErrTXInvalidName     = errors.New("wallet: Transaction name is not valid")
+	ErrFeeTooLow           = errors.New("wallet: Insufficient Fee")
+	ErrNoSuchAddress       = errors.New("wallet: No such address")
+	ErrNoSuchIdentityKey   = errors.New("wallet: No such identity key")
+	ErrNoSuchLabel         = errors.New("wallet: No such label")
+	ErrNoSuchIdentity      = errors.New("wallet: No such identity")
+	ErrWatchOnly           = errors.New("wallet: Address is watch-only and cannot sign")
+	ErrInvalidEncryptedKey = errors.New("wallet: Not a valid encrypted key")
+	ErrTXExists            = errors.New("wallet: Transaction name already exists")
+	ErrTXNotExists         = errors.New("wallet: Transaction name was not found")
+	ErrTXNoInputs          = errors.New("wallet: Transaction has no inputs")
+	ErrTXInvalidName       = errors.New("wallet: Transaction name is not valid")
 )
 
 func (w *Wallet) NewTransaction(name string) error {
@@ -52,6 +58,7 @@ func (w *Wallet) NewTransaction(name string) error {
 	defer w.txlock.Unlock()
 
 	w.transactions[name] = tx
+	delete(w.multisigs, name)
 	return nil
 }
 
@@ -63,6 +70,7 @@ func (w *Wallet) DeleteTransaction(name string) error {
 	w.txlock.Lock()
 	defer w.txlock.Unlock()
 	delete(w.transactions, name)
+	delete(w.multisigs, name)
 	return nil
 }
 
@@ -72,13 +80,10 @@ func (w *Wallet) AddInput(name, address string, amount uint64) error {
 		return err
 	}
 
-	a, err := w.GetFCTAddress(address)
-	if err == leveldb.ErrNotFound {
-		return ErrNoSuchAddress
-	} else if err != nil {
+	adr, rcd, err := w.inputRCD(address)
+	if err != nil {
 		return err
 	}
-	adr := factoid.NewAddress(a.RCDHash())
 
 	// First look if this is really an update
 	for _, input := range tx.GetInputs() {
@@ -90,7 +95,7 @@ func (w *Wallet) AddInput(name, address string, amount uint64) error {
 
 	// Add our new input
 	tx.AddInput(adr, amount)
-	tx.AddRCD(factoid.NewRCD_1(a.PubBytes()))
+	tx.AddRCD(rcd)
 
 	return nil
 }
@@ -177,11 +182,10 @@ func (w *Wallet) AddFee(name, address string, rate uint64) error {
 		return err
 	}
 
-	a, err := w.GetFCTAddress(address)
+	adr, _, err := w.inputRCD(address)
 	if err != nil {
 		return err
 	}
-	adr := factoid.NewAddress(a.RCDHash())
 
 	for _, input := range tx.GetInputs() {
 		if input.GetAddress().IsSameAs(adr) {
@@ -271,18 +275,29 @@ func (w *Wallet) SignTransaction(name string, force bool) error {
 	if len(rcds) == 0 {
 		return ErrTXNoInputs
 	}
-	for i, rcd := range rcds {
-		a, err := rcd.GetAddress()
+	for i := range rcds {
+		a := primitives.ConvertFctAddressToUserStr(tx.GetInputs()[i].GetAddress())
+
+		if r, err := w.GetMultisigAddress(a); err == nil {
+			if err := w.signMultisig(name, tx, i, r, data); err != nil {
+				return err
+			}
+			continue
+		}
+
+		f, err := w.GetFCTSigner(a)
 		if err != nil {
 			return err
 		}
-
-		f, err := w.GetFCTAddress(primitives.ConvertFctAddressToUserStr(a))
+		sig, err := f.Sign(data)
+		if err != nil {
+			return err
+		}
+		block, err := newSignatureBlock(sig)
 		if err != nil {
 			return err
 		}
-		sig := factoid.NewSingleSignatureBlock(f.SecBytes(), data)
-		tx.SetSignatureBlock(i, sig)
+		tx.SetSignatureBlock(i, block)
 	}
 
 	return nil
@@ -350,11 +365,67 @@ func (w *Wallet) ImportComposedTransaction(name string, hexEncoded string) error
 
 	w.txlock.Lock()
 	w.transactions[name] = tx
+	delete(w.multisigs, name)
 	w.txlock.Unlock()
 
 	return nil
 }
 
+// inputRCD returns the Factoid address and RCD for a wallet address that can
+// be used as a transaction input; a Factoid address, an external Signer, or a
+// multisig address.
+func (w *Wallet) inputRCD(address string) (interfaces.IAddress, interfaces.IRCD, error) {
+	if s, ok := w.externalSigner(address); ok {
+		r := factom.NewRCD1()
+		copy(r.Pub[:], s.PubBytes())
+		return factoid.NewAddress(r.Hash()), factoid.NewRCD_1(s.PubBytes()), nil
+	}
+
+	a, err := w.GetFCTAddress(address)
+	if err == nil {
+		return factoid.NewAddress(a.RCDHash()), factoid.NewRCD_1(a.PubBytes()), nil
+	} else if err != ErrNoSuchAddress && err != ErrWatchOnly && err != leveldb.ErrNotFound {
+		return nil, nil, err
+	}
+
+	r, err := w.GetMultisigAddress(address)
+	if err == ErrNoSuchAddress || err == leveldb.ErrNotFound {
+		if w.IsWatchOnly(address) {
+			return nil, nil, ErrWatchOnly
+		}
+		return nil, nil, ErrNoSuchAddress
+	} else if err != nil {
+		return nil, nil, err
+	}
+	rcd, err := multisigRCD(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the input address is the one factomd derives from the RCD, which must
+	// be the address the funds were sent to
+	adr, err := rcd.GetAddress()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(adr.Bytes(), r.Hash()) {
+		return nil, nil, fmt.Errorf("wallet: multisig address %s does not match its factomd RCD", address)
+	}
+	return adr, rcd, nil
+}
+
+// newSignatureBlock creates a signature block from raw 64 byte signatures.
+func newSignatureBlock(sigs ...[]byte) (*factoid.SignatureBlock, error) {
+	block := new(factoid.SignatureBlock)
+	for _, sig := range sigs {
+		s := new(factoid.FactoidSignature)
+		if err := s.SetSignature(sig); err != nil {
+			return nil, err
+		}
+		block.AddSignature(s)
+	}
+	return block, nil
+}
+
 func checkCovered(tx *factoid.Transaction) error {
 	for _, in := range tx.GetInputs() {
 		balance, err := factom.GetFactoidBalance(in.GetUserAddress())
@@ -195,6 +195,49 @@ func (db *TXDatabaseOverlay) GetTXAddress(adr string) (
 	return filtered, nil
 }
 
+// GetTXAddresses returns the transactions that include any of the public
+// Factoid or Entry Credit addresses. Each transaction is returned once.
+func (db *TXDatabaseOverlay) GetTXAddresses(adrs ...string) (
+	[]interfaces.ITransaction, error) {
+	filtered := make([]interfaces.ITransaction, 0)
+
+	want := make(map[string]bool)
+	for _, adr := range adrs {
+		switch factom.AddressStringType(adr) {
+		case factom.FactoidPub, factom.ECPub:
+			want[adr] = true
+		default:
+			return nil, fmt.Errorf("not a valid address")
+		}
+	}
+	if len(want) == 0 {
+		return filtered, nil
+	}
+
+	txs, err := db.GetAllTXs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tx := range txs {
+		found := false
+		for _, in := range tx.GetInputs() {
+			found = found || want[primitives.ConvertFctAddressToUserStr(in.GetAddress())]
+		}
+		for _, out := range tx.GetOutputs() {
+			found = found || want[primitives.ConvertFctAddressToUserStr(out.GetAddress())]
+		}
+		for _, out := range tx.GetECOutputs() {
+			found = found || want[primitives.ConvertECAddressToUserStr(out.GetAddress())]
+		}
+		if found {
+			filtered = append(filtered, tx)
+		}
+	}
+
+	return filtered, nil
+}
+
 func (db *TXDatabaseOverlay) GetTXRange(start, end int) (
 	[]interfaces.ITransaction, error) {
 	if start < 0 || end < 0 || end < start {
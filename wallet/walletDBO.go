@@ -17,16 +17,21 @@ import (
 	"github.com/FactomProject/factomd/database/hybridDB"
 	"github.com/FactomProject/factomd/database/mapdb"
 	"github.com/FactomProject/factomd/database/securedb"
-	"github.com/FactomProject/go-bip32"
 	"github.com/FactomProject/go-bip39"
 )
 
 // Database keys and key prefixes
 var (
-	fcDBPrefix       = []byte("Factoids")
-	ecDBPrefix       = []byte("Entry Credits")
-	seedDBKey        = []byte("DB Seed")
-	identityDBPrefix = []byte("Identities")
+	fcDBPrefix            = []byte("Factoids")
+	ecDBPrefix            = []byte("Entry Credits")
+	seedDBKey             = []byte("DB Seed")
+	identityDBPrefix      = []byte("Identities")
+	multisigDBPrefix      = []byte("Multisig Addresses")
+	watchDBPrefix         = []byte("Watch Only")
+	labelDBPrefix         = []byte("Labels")
+	contactDBPrefix       = []byte("Address Book")
+	identityStateDBPrefix = []byte("Identity States")
+	walletIDDBPrefix      = []byte("Wallet Identities")
 )
 
 type WalletDatabaseOverlay struct {
@@ -150,6 +155,15 @@ type DBSeedBase struct {
 	NextFactoidAddressIndex uint32
 	NextECAddressIndex      uint32
 	NextIdentityKeyIndex    uint32
+
+	// Passphrase is the optional BIP39 passphrase for the MnemonicSeed.
+	Passphrase string
+	// FactoidPath, ECPath, and IdentityPath override the default BIP44
+	// derivation paths. The address component of each path is replaced by
+	// the next index.
+	FactoidPath  *factom.DerivationPath
+	ECPath       *factom.DerivationPath
+	IdentityPath *factom.DerivationPath
 }
 
 type DBSeed struct {
@@ -204,12 +218,11 @@ func (e *DBSeed) String() string {
 }
 
 func (e *DBSeed) NextFCTAddress() (*factom.FactoidAddress, error) {
-	add, err := factom.MakeBIP44FactoidAddress(
-		e.MnemonicSeed,
-		bip32.FirstHardenedChild,
-		0,
-		e.NextFactoidAddressIndex,
-	)
+	path := factom.NewFactoidPath(e.NextFactoidAddressIndex)
+	if e.FactoidPath != nil {
+		path = e.FactoidPath.WithAddress(e.NextFactoidAddressIndex)
+	}
+	add, err := factom.MakeFactoidAddressFromPath(e.MnemonicSeed, e.Passphrase, path)
 	if err != nil {
 		return nil, err
 	}
@@ -218,12 +231,11 @@ func (e *DBSeed) NextFCTAddress() (*factom.FactoidAddress, error) {
 }
 
 func (e *DBSeed) NextECAddress() (*factom.ECAddress, error) {
-	add, err := factom.MakeBIP44ECAddress(
-		e.MnemonicSeed,
-		bip32.FirstHardenedChild,
-		0,
-		e.NextECAddressIndex,
-	)
+	path := factom.NewECPath(e.NextECAddressIndex)
+	if e.ECPath != nil {
+		path = e.ECPath.WithAddress(e.NextECAddressIndex)
+	}
+	add, err := factom.MakeECAddressFromPath(e.MnemonicSeed, e.Passphrase, path)
 	if err != nil {
 		return nil, err
 	}
@@ -232,12 +244,11 @@ func (e *DBSeed) NextECAddress() (*factom.ECAddress, error) {
 }
 
 func (e *DBSeed) NextIdentityKey() (*factom.IdentityKey, error) {
-	add, err := factom.MakeBIP44IdentityKey(
-		e.MnemonicSeed,
-		bip32.FirstHardenedChild,
-		0,
-		e.NextIdentityKeyIndex,
-	)
+	path := factom.NewIdentityPath(e.NextIdentityKeyIndex)
+	if e.IdentityPath != nil {
+		path = e.IdentityPath.WithAddress(e.NextIdentityKeyIndex)
+	}
+	add, err := factom.MakeIdentityKeyFromPath(e.MnemonicSeed, e.Passphrase, path)
 	if err != nil {
 		return nil, err
 	}
@@ -355,7 +366,10 @@ func (db *WalletDatabaseOverlay) InsertECAddress(e *factom.ECAddress) error {
 	batch := []interfaces.Record{}
 	batch = append(batch, interfaces.Record{ecDBPrefix, []byte(e.PubString()), e})
 
-	return db.DBO.PutInBatch(batch)
+	if err := db.DBO.PutInBatch(batch); err != nil {
+		return err
+	}
+	return db.promoteWatchOnly(e.PubString())
 }
 
 func (db *WalletDatabaseOverlay) GetECAddress(pubString string) (*factom.ECAddress, error) {
@@ -364,6 +378,9 @@ func (db *WalletDatabaseOverlay) GetECAddress(pubString string) (*factom.ECAddre
 		return nil, err
 	}
 	if data == nil {
+		if db.IsWatchOnly(pubString) {
+			return nil, ErrWatchOnly
+		}
 		return nil, ErrNoSuchAddress
 	}
 	return data.(*factom.ECAddress), nil
@@ -407,7 +424,10 @@ func (db *WalletDatabaseOverlay) InsertFCTAddress(e *factom.FactoidAddress) erro
 	batch := []interfaces.Record{}
 	batch = append(batch, interfaces.Record{fcDBPrefix, []byte(e.String()), e})
 
-	return db.DBO.PutInBatch(batch)
+	if err := db.DBO.PutInBatch(batch); err != nil {
+		return err
+	}
+	return db.promoteWatchOnly(e.String())
 }
 
 func (db *WalletDatabaseOverlay) GetFCTAddress(str string) (*factom.FactoidAddress, error) {
@@ -416,6 +436,9 @@ func (db *WalletDatabaseOverlay) GetFCTAddress(str string) (*factom.FactoidAddre
 		return nil, err
 	}
 	if data == nil {
+		if db.IsWatchOnly(str) {
+			return nil, ErrWatchOnly
+		}
 		return nil, ErrNoSuchAddress
 	}
 	return data.(*factom.FactoidAddress), nil
@@ -438,7 +461,19 @@ func toFList(source []interfaces.BinaryMarshallableAndCopyable) []*factom.Factoi
 	return answer
 }
 
+// RemoveAddress removes a Factoid or Entry Credit address, or a watch-only
+// address, from the database along with its label.
 func (db *WalletDatabaseOverlay) RemoveAddress(pubString string) error {
+	if err := db.removeAddress(pubString); err != nil {
+		return err
+	}
+	if err := db.RemoveLabel(pubString); err != nil && err != ErrNoSuchLabel {
+		return err
+	}
+	return nil
+}
+
+func (db *WalletDatabaseOverlay) removeAddress(pubString string) error {
 	if len(pubString) == 0 {
 		return nil
 	}
@@ -448,7 +483,10 @@ func (db *WalletDatabaseOverlay) RemoveAddress(pubString string) error {
 			return err
 		}
 		if data == nil {
-			return ErrNoSuchAddress
+			return db.RemoveWatchOnlyAddress(pubString)
+		}
+		if err := db.removeStaleWatchOnly(pubString); err != nil {
+			return err
 		}
 		err = db.DBO.Delete(fcDBPrefix, []byte(pubString))
 		if err == nil {
@@ -463,7 +501,10 @@ func (db *WalletDatabaseOverlay) RemoveAddress(pubString string) error {
 			return err
 		}
 		if data == nil {
-			return ErrNoSuchAddress
+			return db.RemoveWatchOnlyAddress(pubString)
+		}
+		if err := db.removeStaleWatchOnly(pubString); err != nil {
+			return err
 		}
 		err = db.DBO.Delete(ecDBPrefix, []byte(pubString))
 		if err == nil {
@@ -622,3 +663,438 @@ func (t *ID) New() interfaces.BinaryMarshallableAndCopyable {
 	e.IdentityKey = factom.NewIdentityKey()
 	return e
 }
+
+func (db *WalletDatabaseOverlay) InsertMultisigAddress(r *factom.RCD2) error {
+	if r == nil {
+		return nil
+	}
+
+	batch := []interfaces.Record{}
+	batch = append(batch, interfaces.Record{multisigDBPrefix, []byte(r.String()), r})
+
+	return db.DBO.PutInBatch(batch)
+}
+
+func (db *WalletDatabaseOverlay) GetMultisigAddress(str string) (*factom.RCD2, error) {
+	data, err := db.DBO.Get(multisigDBPrefix, []byte(str), new(factom.RCD2))
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrNoSuchAddress
+	}
+	return data.(*factom.RCD2), nil
+}
+
+func (db *WalletDatabaseOverlay) GetAllMultisigAddresses() ([]*factom.RCD2, error) {
+	list, err := db.DBO.FetchAllBlocksFromBucket(multisigDBPrefix, new(MSA))
+	if err != nil {
+		return nil, err
+	}
+	return toMultisigList(list), nil
+}
+
+func toMultisigList(source []interfaces.BinaryMarshallableAndCopyable) []*factom.RCD2 {
+	answer := make([]*factom.RCD2, len(source))
+	for i, v := range source {
+		answer[i] = v.(*MSA).RCD2
+	}
+	sort.Sort(byMultisigName(answer))
+	return answer
+}
+
+func (db *WalletDatabaseOverlay) RemoveMultisigAddress(str string) error {
+	if len(str) == 0 {
+		return nil
+	}
+
+	data, err := db.DBO.Get(multisigDBPrefix, []byte(str), new(factom.RCD2))
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return ErrNoSuchAddress
+	}
+	err = db.DBO.Delete(multisigDBPrefix, []byte(str))
+	if err == nil {
+		err := db.DBO.Delete(multisigDBPrefix, []byte(str)) //delete twice to flush the db file
+		return err
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+type byMultisigName []*factom.RCD2
+
+func (f byMultisigName) Len() int {
+	return len(f)
+}
+func (f byMultisigName) Less(i, j int) bool {
+	a := strings.Compare(f[i].String(), f[j].String())
+	return a < 0
+}
+func (f byMultisigName) Swap(i, j int) {
+	f[i], f[j] = f[j], f[i]
+}
+
+type MSA struct {
+	*factom.RCD2
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*MSA)(nil)
+
+func (t *MSA) New() interfaces.BinaryMarshallableAndCopyable {
+	e := new(MSA)
+	e.RCD2 = new(factom.RCD2)
+	return e
+}
+
+func (db *WalletDatabaseOverlay) InsertWatchOnlyAddress(a *factom.WatchOnlyAddress) error {
+	if a == nil {
+		return nil
+	}
+
+	batch := []interfaces.Record{}
+	batch = append(batch, interfaces.Record{watchDBPrefix, []byte(a.Address), a})
+
+	return db.DBO.PutInBatch(batch)
+}
+
+func (db *WalletDatabaseOverlay) GetWatchOnlyAddress(str string) (*factom.WatchOnlyAddress, error) {
+	data, err := db.DBO.Get(watchDBPrefix, []byte(str), new(factom.WatchOnlyAddress))
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrNoSuchAddress
+	}
+	return data.(*factom.WatchOnlyAddress), nil
+}
+
+// IsWatchOnly returns true if the public address is tracked as watch-only.
+func (db *WalletDatabaseOverlay) IsWatchOnly(str string) bool {
+	_, err := db.GetWatchOnlyAddress(str)
+	return err == nil
+}
+
+func (db *WalletDatabaseOverlay) GetAllWatchOnlyAddresses() ([]*factom.WatchOnlyAddress, error) {
+	list, err := db.DBO.FetchAllBlocksFromBucket(watchDBPrefix, new(WOA))
+	if err != nil {
+		return nil, err
+	}
+	return toWatchOnlyList(list), nil
+}
+
+func toWatchOnlyList(source []interfaces.BinaryMarshallableAndCopyable) []*factom.WatchOnlyAddress {
+	answer := make([]*factom.WatchOnlyAddress, len(source))
+	for i, v := range source {
+		answer[i] = v.(*WOA).WatchOnlyAddress
+	}
+	sort.Sort(byWatchOnlyName(answer))
+	return answer
+}
+
+func (db *WalletDatabaseOverlay) RemoveWatchOnlyAddress(str string) error {
+	if len(str) == 0 {
+		return nil
+	}
+
+	data, err := db.DBO.Get(watchDBPrefix, []byte(str), new(factom.WatchOnlyAddress))
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return ErrNoSuchAddress
+	}
+	err = db.DBO.Delete(watchDBPrefix, []byte(str))
+	if err == nil {
+		err := db.DBO.Delete(watchDBPrefix, []byte(str)) //delete twice to flush the db file
+		return err
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+// promoteWatchOnly removes the watch-only record of an address whose secret
+// key was added to the database, so that the address is only listed once. The
+// watch-only label is kept unless the address already has one.
+func (db *WalletDatabaseOverlay) promoteWatchOnly(str string) error {
+	a, err := db.GetWatchOnlyAddress(str)
+	if err == ErrNoSuchAddress {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if a.Label != "" {
+		if _, err := db.GetLabel(str); err == ErrNoSuchLabel {
+			l, err := factom.NewAddressLabel(str, a.Label, nil, "")
+			if err != nil {
+				return err
+			}
+			if err := db.InsertLabel(l); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	return db.RemoveWatchOnlyAddress(str)
+}
+
+// removeStaleWatchOnly removes a watch-only record left next to a secret key
+// by older versions of the wallet.
+func (db *WalletDatabaseOverlay) removeStaleWatchOnly(str string) error {
+	if err := db.RemoveWatchOnlyAddress(str); err != nil && err != ErrNoSuchAddress {
+		return err
+	}
+	return nil
+}
+
+type byWatchOnlyName []*factom.WatchOnlyAddress
+
+func (f byWatchOnlyName) Len() int {
+	return len(f)
+}
+func (f byWatchOnlyName) Less(i, j int) bool {
+	a := strings.Compare(f[i].Address, f[j].Address)
+	return a < 0
+}
+func (f byWatchOnlyName) Swap(i, j int) {
+	f[i], f[j] = f[j], f[i]
+}
+
+type WOA struct {
+	*factom.WatchOnlyAddress
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*WOA)(nil)
+
+func (t *WOA) New() interfaces.BinaryMarshallableAndCopyable {
+	e := new(WOA)
+	e.WatchOnlyAddress = new(factom.WatchOnlyAddress)
+	return e
+}
+
+func (db *WalletDatabaseOverlay) InsertLabel(l *factom.AddressLabel) error {
+	return db.insertAddressLabel(labelDBPrefix, l)
+}
+
+func (db *WalletDatabaseOverlay) GetLabel(address string) (*factom.AddressLabel, error) {
+	return db.getAddressLabel(labelDBPrefix, address)
+}
+
+func (db *WalletDatabaseOverlay) GetAllLabels() ([]*factom.AddressLabel, error) {
+	return db.getAllAddressLabels(labelDBPrefix)
+}
+
+func (db *WalletDatabaseOverlay) RemoveLabel(address string) error {
+	return db.removeAddressLabel(labelDBPrefix, address)
+}
+
+func (db *WalletDatabaseOverlay) InsertContact(l *factom.AddressLabel) error {
+	return db.insertAddressLabel(contactDBPrefix, l)
+}
+
+func (db *WalletDatabaseOverlay) GetContact(address string) (*factom.AddressLabel, error) {
+	return db.getAddressLabel(contactDBPrefix, address)
+}
+
+func (db *WalletDatabaseOverlay) GetAllContacts() ([]*factom.AddressLabel, error) {
+	return db.getAllAddressLabels(contactDBPrefix)
+}
+
+func (db *WalletDatabaseOverlay) RemoveContact(address string) error {
+	return db.removeAddressLabel(contactDBPrefix, address)
+}
+
+// InsertIdentityState stores the identity key state cached by a
+// factom.IdentityKeyCache.
+func (db *WalletDatabaseOverlay) InsertIdentityState(s *factom.IdentityState) error {
+	if s == nil {
+		return nil
+	}
+
+	batch := []interfaces.Record{}
+	batch = append(batch, interfaces.Record{identityStateDBPrefix, []byte(s.ChainID), s})
+
+	return db.DBO.PutInBatch(batch)
+}
+
+// GetIdentityState returns the cached identity key state, or nil if the
+// identity is not cached.
+func (db *WalletDatabaseOverlay) GetIdentityState(chainID string) (*factom.IdentityState, error) {
+	data, err := db.DBO.Get(identityStateDBPrefix, []byte(chainID), new(factom.IdentityState))
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+	return data.(*factom.IdentityState), nil
+}
+
+func (db *WalletDatabaseOverlay) RemoveIdentityState(chainID string) error {
+	return db.DBO.Delete(identityStateDBPrefix, []byte(chainID))
+}
+
+func (db *WalletDatabaseOverlay) InsertWalletIdentity(i *factom.WalletIdentity) error {
+	if i == nil {
+		return nil
+	}
+
+	batch := []interfaces.Record{}
+	batch = append(batch, interfaces.Record{walletIDDBPrefix, []byte(i.ChainID), i})
+
+	return db.DBO.PutInBatch(batch)
+}
+
+func (db *WalletDatabaseOverlay) GetWalletIdentity(chainID string) (*factom.WalletIdentity, error) {
+	data, err := db.DBO.Get(walletIDDBPrefix, []byte(chainID), new(factom.WalletIdentity))
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrNoSuchIdentity
+	}
+	return data.(*factom.WalletIdentity), nil
+}
+
+func (db *WalletDatabaseOverlay) GetAllWalletIdentities() ([]*factom.WalletIdentity, error) {
+	list, err := db.DBO.FetchAllBlocksFromBucket(walletIDDBPrefix, new(WID))
+	if err != nil {
+		return nil, err
+	}
+	answer := make([]*factom.WalletIdentity, len(list))
+	for i, v := range list {
+		answer[i] = v.(*WID).WalletIdentity
+	}
+	sort.Sort(byIdentityChainID(answer))
+	return answer, nil
+}
+
+func (db *WalletDatabaseOverlay) RemoveWalletIdentity(chainID string) error {
+	if _, err := db.GetWalletIdentity(chainID); err != nil {
+		return err
+	}
+	return db.DBO.Delete(walletIDDBPrefix, []byte(chainID))
+}
+
+type byIdentityChainID []*factom.WalletIdentity
+
+func (f byIdentityChainID) Len() int {
+	return len(f)
+}
+func (f byIdentityChainID) Less(i, j int) bool {
+	a := strings.Compare(f[i].ChainID, f[j].ChainID)
+	return a < 0
+}
+func (f byIdentityChainID) Swap(i, j int) {
+	f[i], f[j] = f[j], f[i]
+}
+
+type WID struct {
+	*factom.WalletIdentity
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*WID)(nil)
+
+func (t *WID) New() interfaces.BinaryMarshallableAndCopyable {
+	e := new(WID)
+	e.WalletIdentity = new(factom.WalletIdentity)
+	return e
+}
+
+func (db *WalletDatabaseOverlay) insertAddressLabel(bucket []byte, l *factom.AddressLabel) error {
+	if l == nil {
+		return nil
+	}
+
+	batch := []interfaces.Record{}
+	batch = append(batch, interfaces.Record{bucket, []byte(l.Address), l})
+
+	return db.DBO.PutInBatch(batch)
+}
+
+func (db *WalletDatabaseOverlay) getAddressLabel(bucket []byte, address string) (*factom.AddressLabel, error) {
+	data, err := db.DBO.Get(bucket, []byte(address), new(factom.AddressLabel))
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrNoSuchLabel
+	}
+	return data.(*factom.AddressLabel), nil
+}
+
+func (db *WalletDatabaseOverlay) getAllAddressLabels(bucket []byte) ([]*factom.AddressLabel, error) {
+	list, err := db.DBO.FetchAllBlocksFromBucket(bucket, new(AL))
+	if err != nil {
+		return nil, err
+	}
+	return toLabelList(list), nil
+}
+
+func (db *WalletDatabaseOverlay) removeAddressLabel(bucket []byte, address string) error {
+	if len(address) == 0 {
+		return nil
+	}
+
+	data, err := db.DBO.Get(bucket, []byte(address), new(factom.AddressLabel))
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return ErrNoSuchLabel
+	}
+	err = db.DBO.Delete(bucket, []byte(address))
+	if err == nil {
+		err := db.DBO.Delete(bucket, []byte(address)) //delete twice to flush the db file
+		return err
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+func toLabelList(source []interfaces.BinaryMarshallableAndCopyable) []*factom.AddressLabel {
+	answer := make([]*factom.AddressLabel, len(source))
+	for i, v := range source {
+		answer[i] = v.(*AL).AddressLabel
+	}
+	sort.Sort(byLabel(answer))
+	return answer
+}
+
+type byLabel []*factom.AddressLabel
+
+func (f byLabel) Len() int {
+	return len(f)
+}
+func (f byLabel) Less(i, j int) bool {
+	a := strings.Compare(f[i].Label, f[j].Label)
+	if a == 0 {
+		return f[i].Address < f[j].Address
+	}
+	return a < 0
+}
+func (f byLabel) Swap(i, j int) {
+	f[i], f[j] = f[j], f[i]
+}
+
+type AL struct {
+	*factom.AddressLabel
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*AL)(nil)
+
+func (t *AL) New() interfaces.BinaryMarshallableAndCopyable {
+	e := new(AL)
+	e.AddressLabel = new(factom.AddressLabel)
+	return e
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/FactomProject/factom"
 	. "github.com/FactomProject/factom/wallet"
 )
 
@@ -107,3 +108,37 @@ func TestDBSeed(t *testing.T) {
 		t.Errorf("Wrong NextECAddressIndex")
 	}
 }
+
+func TestIdentityStateStore(t *testing.T) {
+	db := NewMapDB()
+	var _ factom.IdentityStateStore = db
+
+	chainID := "e0cf1713b492e09e783d5d9f4fc6e2c71b5bdc9af4806a7937a5e935819717e9"
+	if s, err := db.GetIdentityState(chainID); err != nil || s != nil {
+		t.Errorf("expected no state, found %v %v", s, err)
+	}
+
+	s := &factom.IdentityState{
+		ChainID:     chainID,
+		InitialKeys: []string{"idpub2TWHFrWrJxVEmbeXnMRWeKBdFp7bEByosS1phV1bH7NS99zHF9"},
+		KeyMR:       "0000000000000000000000000000000000000000000000000000000000000001",
+		Height:      10,
+	}
+	if err := db.InsertIdentityState(s); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.GetIdentityState(chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.KeyMR != s.KeyMR || got.Height != s.Height || got.Keys()[0] != s.InitialKeys[0] {
+		t.Errorf("expected %+v, found %+v", s, got)
+	}
+
+	if err := db.RemoveIdentityState(chainID); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := db.GetIdentityState(chainID); err != nil || s != nil {
+		t.Errorf("expected the state to be removed, found %v %v", s, err)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factom"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// ImportWatchOnlyAddress adds a public Factoid or Entry Credit address to the
+// Wallet without its secret key. Watch-only addresses are included in balance
+// and transaction queries but are refused when signing.
+func (w *Wallet) ImportWatchOnlyAddress(address, label string) (*factom.WatchOnlyAddress, error) {
+	a, err := factom.NewWatchOnlyAddress(address, label)
+	if err != nil {
+		return nil, err
+	}
+
+	// an address with a secret key in the wallet is already being watched
+	if a.IsFactoid() {
+		if _, err := w.GetFCTAddress(address); err == nil {
+			return nil, fmt.Errorf("wallet: %s is already in the wallet", address)
+		}
+	} else {
+		if _, err := w.GetECAddress(address); err == nil {
+			return nil, fmt.Errorf("wallet: %s is already in the wallet", address)
+		}
+	}
+
+	if err := w.InsertWatchOnlyAddress(a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetWatchOnlyTXs returns the transactions from the transaction database that
+// include any of the watch-only addresses.
+func (w *Wallet) GetWatchOnlyTXs() ([]interfaces.ITransaction, error) {
+	if w.txdb == nil {
+		return nil, fmt.Errorf("wallet: Wallet does not have a transaction database")
+	}
+
+	as, err := w.GetAllWatchOnlyAddresses()
+	if err != nil {
+		return nil, err
+	}
+	adrs := make([]string, len(as))
+	for i, a := range as {
+		adrs[i] = a.Address
+	}
+
+	return w.txdb.GetTXAddresses(adrs...)
+}
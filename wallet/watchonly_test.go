@@ -0,0 +1,127 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wallet_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factom"
+	. "github.com/FactomProject/factom/wallet"
+)
+
+func TestWatchOnlyAddress(t *testing.T) {
+	zSec := "Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj"
+	cold := "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"
+
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+
+	f, err := factom.GetFactoidAddress(zSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.InsertFCTAddress(f); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w1.ImportWatchOnlyAddress(cold, "cold storage"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w1.ImportWatchOnlyAddress(f.String(), ""); err == nil {
+		t.Error("expected error watching an address already in the wallet")
+	}
+	if _, err := w1.ImportWatchOnlyAddress(zSec, ""); err == nil {
+		t.Error("expected error watching a secret address")
+	}
+
+	as, err := w1.GetAllWatchOnlyAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(as) != 1 || as[0].Address != cold || as[0].Label != "cold storage" {
+		t.Errorf("unexpected watch-only addresses %v", as)
+	}
+
+	// signing paths refuse the watch-only address
+	if _, err := w1.GetFCTAddress(cold); err != ErrWatchOnly {
+		t.Errorf("expected ErrWatchOnly, got %v", err)
+	}
+	if err := w1.NewTransaction("tx"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w1.AddInput("tx", cold, 1000); err != ErrWatchOnly {
+		t.Errorf("expected ErrWatchOnly, got %v", err)
+	}
+
+	if err := w1.RemoveAddress(cold); err != nil {
+		t.Error(err)
+	}
+	if w1.IsWatchOnly(cold) {
+		t.Error("watch-only address was not removed")
+	}
+}
+
+func TestWatchOnlyBalanceAddresses(t *testing.T) {
+	zSec := "Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj"
+
+	w1, err := NewMapDBWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w1.Close()
+
+	f, err := factom.GetFactoidAddress(zSec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w1.ImportWatchOnlyAddress(f.String(), "savings"); err != nil {
+		t.Fatal(err)
+	}
+
+	// wallet-balances totals the wallet addresses and the watch-only
+	// addresses; an address whose secret is imported must only be counted once
+	if err := w1.InsertFCTAddress(f); err != nil {
+		t.Fatal(err)
+	}
+	fs, _, err := w1.GetAllAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws, err := w1.GetAllWatchOnlyAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counted := 0
+	for _, a := range fs {
+		if a.String() == f.String() {
+			counted++
+		}
+	}
+	for _, a := range ws {
+		if a.Address == f.String() {
+			counted++
+		}
+	}
+	if counted != 1 {
+		t.Errorf("address counted %d times in the balances", counted)
+	}
+	if l := w1.LookupLabel(f.String()); l != "savings" {
+		t.Errorf("expected the watch-only label to be kept, found %q", l)
+	}
+
+	// removing the secret does not leave the address watched
+	if err := w1.RemoveAddress(f.String()); err != nil {
+		t.Fatal(err)
+	}
+	if w1.IsWatchOnly(f.String()) {
+		t.Error("removed address is still watch-only")
+	}
+	if _, err := w1.GetFCTAddress(f.String()); err != ErrNoSuchAddress {
+		t.Errorf("expected ErrNoSuchAddress, got %v", err)
+	}
+}
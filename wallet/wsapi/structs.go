@@ -33,6 +33,7 @@ type importRequest struct {
 	Addresses []struct {
 		Secret string `json:"secret"`
 	} `json:addresses`
+	Password string `json:"password,omitempty"`
 }
 
 type importKoinifyRequest struct {
@@ -56,9 +57,10 @@ type transactionAddressRequest struct {
 }
 
 type txdbRequest struct {
-	TxID    string `json:"txid,omitempty"`
-	Address string `json:"address,omitempty"`
-	Range   struct {
+	TxID      string `json:"txid,omitempty"`
+	Address   string `json:"address,omitempty"`
+	WatchOnly bool   `json:"watchonly,omitempty"`
+	Range     struct {
 		Start int `json:"start"`
 		End   int `json:"end"`
 	} `json:"range,omitempty"`
@@ -68,6 +70,9 @@ type entryRequest struct {
 	Entry factom.Entry `json:"entry"`
 	ECPub string       `json:"ecpub"`
 	Force bool         `json:"force"`
+	// SignerKey and SignerChainID optionally sign the entry with an identity
+	SignerKey     string `json:"signerkey,omitempty"`
+	SignerChainID string `json:"signer-chainid,omitempty"`
 }
 
 type chainRequest struct {
@@ -84,6 +89,77 @@ type importIdentityKeysRequest struct {
 	Keys []struct {
 		Secret string `json:"secret"`
 	} `json:keys`
+	Password string `json:"password,omitempty"`
+}
+
+type exportEncryptedKeysRequest struct {
+	Password  string   `json:"password"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+type signDataRequest struct {
+	Signer string `json:"signer"`
+	Data   []byte `json:"data"`
+}
+
+type verifyDataRequest struct {
+	Signer    string `json:"signer"`
+	Data      []byte `json:"data"`
+	PubKey    []byte `json:"pubkey"`
+	Signature []byte `json:"signature"`
+}
+
+type seedSharesRequest struct {
+	Threshold int `json:"threshold"`
+	Shares    int `json:"shares"`
+}
+
+type restoreSeedSharesRequest struct {
+	Shares   []string `json:"shares"`
+	GapLimit uint32   `json:"gap-limit,omitempty"`
+	derivationRequest
+}
+
+// derivationRequest holds the BIP39 passphrase and BIP44 paths of a seed.
+// Empty paths use the Factom defaults.
+type derivationRequest struct {
+	Passphrase   string `json:"passphrase,omitempty"`
+	FactoidPath  string `json:"factoid-path,omitempty"`
+	ECPath       string `json:"ec-path,omitempty"`
+	IdentityPath string `json:"identity-path,omitempty"`
+}
+
+type restoreSeedRequest struct {
+	Mnemonic string `json:"mnemonic"`
+	GapLimit uint32 `json:"gap-limit,omitempty"`
+	derivationRequest
+}
+
+type recoverAddressesRequest struct {
+	GapLimit uint32 `json:"gap-limit,omitempty"`
+}
+
+type walletBackupRequest struct {
+	Password string `json:"password,omitempty"`
+}
+
+type importMultisigRequest struct {
+	Required int      `json:"required"`
+	Members  []string `json:"members"`
+}
+
+type multisigSignaturesRequest struct {
+	Name       string                      `json:"tx-name"`
+	Address    string                      `json:"address"`
+	Signatures []*factom.MultisigSignature `json:"signatures,omitempty"`
+}
+
+type addSignerRequest struct {
+	Socket string `json:"socket"`
+}
+
+type importWatchOnlyRequest struct {
+	Addresses []*factom.WatchOnlyAddress `json:"addresses"`
 }
 
 type activeIdentityKeysRequest struct {
@@ -98,13 +174,18 @@ type identityChainRequest struct {
 	Force   bool     `json:"force"`
 }
 
+type walletIdentityRequest struct {
+	ChainID string `json:"chainid"`
+}
+
 type identityKeyReplacementRequest struct {
-	ChainID   string `json:"chainid"`
-	OldKey    string `json:"oldkey"`
-	NewKey    string `json:"newkey"`
-	SignerKey string `json:"signerkey"`
-	ECPub     string `json:"ecpub"`
-	Force     bool   `json:"force"`
+	ChainID     string `json:"chainid"`
+	OldKey      string `json:"oldkey"`
+	NewKey      string `json:"newkey"`
+	SignerKey   string `json:"signerkey"`
+	ECPub       string `json:"ecpub"`
+	Force       bool   `json:"force"`
+	SkipRefresh bool   `json:"skip-refresh"`
 }
 
 type identityAttributeRequest struct {
@@ -115,6 +196,7 @@ type identityAttributeRequest struct {
 	SignerChainID      string                     `json:"signer-chainid"`
 	ECPub              string                     `json:"ecpub"`
 	Force              bool                       `json:"force"`
+	SkipRefresh        bool                       `json:"skip-refresh"`
 }
 
 type identityAttributeEndorsementRequest struct {
@@ -124,6 +206,7 @@ type identityAttributeEndorsementRequest struct {
 	SignerChainID      string `json:"signer-chainid"`
 	ECPub              string `json:"ecpub"`
 	Force              bool   `json:"force"`
+	SkipRefresh        bool   `json:"skip-refresh"`
 }
 
 // responses
@@ -131,12 +214,32 @@ type identityAttributeEndorsementRequest struct {
 type addressResponse struct {
 	Public string `json:"public"`
 	Secret string `json:"secret"`
+	Label  string `json:"label,omitempty"`
+
+	// multisig addresses have no secret, only the required signatures and
+	// the member addresses
+	Required int      `json:"required,omitempty"`
+	Members  []string `json:"members,omitempty"`
 }
 
 type multiAddressResponse struct {
 	Addresses []*addressResponse `json:"addresses"`
 }
 
+type recoverAddressesResponse struct {
+	Addresses               []*addressResponse `json:"addresses"`
+	NextFactoidAddressIndex uint32             `json:"next-factoid-address-index"`
+	NextECAddressIndex      uint32             `json:"next-ec-address-index"`
+}
+
+type multisigSignaturesResponse struct {
+	Signatures []*factom.MultisigSignature `json:"signatures"`
+}
+
+type signersResponse struct {
+	Signers []*factom.SignerKey `json:"signers"`
+}
+
 type balanceResponse struct {
 	CurrentHeight   uint32        `json:"current-height"`
 	LastSavedHeight uint          `json:"last-saved-height"`
@@ -152,12 +255,40 @@ type multiBalanceResponse struct {
 		Ack   int64 `json:"ack"`
 		Saved int64 `json:"saved"`
 	} `json:"ecaccountbalances"`
+	WatchOnlyFactoidBalances struct {
+		Ack   int64 `json:"ack"`
+		Saved int64 `json:"saved"`
+	} `json:"watchonlyfctbalances"`
+	WatchOnlyEntryCreditBalances struct {
+		Ack   int64 `json:"ack"`
+		Saved int64 `json:"saved"`
+	} `json:"watchonlyecbalances"`
 }
 
 type walletBackupResponse struct {
-	Seed         string                 `json:"wallet-seed"`
+	Seed         string                 `json:"wallet-seed,omitempty"`
+	Passphrase   string                 `json:"wallet-passphrase,omitempty"`
+	FactoidPath  string                 `json:"factoid-path"`
+	ECPath       string                 `json:"ec-path"`
+	IdentityPath string                 `json:"identity-path"`
 	Addresses    []*addressResponse     `json:"addresses"`
 	IdentityKeys []*identityKeyResponse `json:"identity-keys"`
+	Multisig     []*addressResponse     `json:"multisig-addresses"`
+	Labels       []*factom.AddressLabel `json:"labels"`
+	Contacts     []*factom.AddressLabel `json:"contacts"`
+}
+
+type verifyDataResponse struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+type walletIdentitiesResponse struct {
+	Identities []*factom.WalletIdentity `json:"identities"`
+}
+
+type seedSharesResponse struct {
+	Shares []string `json:"shares"`
 }
 
 type multiTransactionResponse struct {
@@ -192,6 +323,23 @@ type identityKeyResponse struct {
 	Secret string `json:"secret,omitempty"`
 }
 
+type multiLabelResponse struct {
+	Labels []*factom.AddressLabel `json:"labels"`
+}
+
+type restoreLabelsRequest struct {
+	Labels   []*factom.AddressLabel `json:"labels"`
+	Contacts []*factom.AddressLabel `json:"contacts"`
+}
+
+type restoreLabelsResponse struct {
+	Skipped []*factom.AddressLabel `json:"skipped"`
+}
+
+type multiWatchOnlyResponse struct {
+	Addresses []*factom.WatchOnlyAddress `json:"addresses"`
+}
+
 type multiIdentityKeyResponse struct {
 	Keys []*identityKeyResponse `json:"keys"`
 }
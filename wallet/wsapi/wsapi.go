@@ -37,6 +37,7 @@ const APIVersion string = "2.0"
 var (
 	webServer *web.Server
 	fctWallet *wallet.Wallet
+	idCache   *factom.IdentityKeyCache
 	rpcUser   string
 	rpcPass   string
 	authsha   []byte
@@ -107,6 +108,7 @@ func fileExists(name string) bool {
 func Start(w *wallet.Wallet, net string, c factom.RPCConfig) {
 	webServer = web.NewServer()
 	fctWallet = w
+	idCache = factom.NewIdentityKeyCache(w)
 
 	if len(c.WalletCORSDomains) > 0 {
 		domains := strings.Split(c.WalletCORSDomains, ",")
@@ -239,10 +241,54 @@ func handleV2Request(j *factom.JSON2Request) (*factom.JSON2Response, *factom.JSO
 			resp, jsonError = handleGenerateFactoidAddress(params)
 		case "import-addresses":
 			resp, jsonError = handleImportAddresses(params)
+		case "import-multisig-address":
+			resp, jsonError = handleImportMultisigAddress(params)
+		case "multisig-addresses":
+			resp, jsonError = handleMultisigAddresses(params)
+		case "multisig-signatures":
+			resp, jsonError = handleMultisigSignatures(params)
+		case "add-multisig-signatures":
+			resp, jsonError = handleAddMultisigSignatures(params)
+		case "add-signer":
+			resp, jsonError = handleAddSigner(params)
+		case "signers":
+			resp, jsonError = handleSigners(params)
 		case "import-koinify":
 			resp, jsonError = handleImportKoinify(params)
+		case "import-watch-only-addresses":
+			resp, jsonError = handleImportWatchOnlyAddresses(params)
+		case "watch-only-addresses":
+			resp, jsonError = handleWatchOnlyAddresses(params)
+		case "set-label":
+			resp, jsonError = handleSetLabel(params)
+		case "labels":
+			resp, jsonError = handleLabels(params)
+		case "remove-label":
+			resp, jsonError = handleRemoveLabel(params)
+		case "restore-labels":
+			resp, jsonError = handleRestoreLabels(params)
+		case "add-contact":
+			resp, jsonError = handleAddContact(params)
+		case "contacts":
+			resp, jsonError = handleContacts(params)
+		case "remove-contact":
+			resp, jsonError = handleRemoveContact(params)
+		case "export-encrypted-keys":
+			resp, jsonError = handleExportEncryptedKeys(params)
 		case "wallet-backup":
 			resp, jsonError = handleWalletBackup(params)
+		case "sign-data":
+			resp, jsonError = handleSignData(params)
+		case "verify-data":
+			resp, jsonError = handleVerifyData(params)
+		case "wallet-backup-shares":
+			resp, jsonError = handleWalletBackupShares(params)
+		case "restore-seed-shares":
+			resp, jsonError = handleRestoreSeedShares(params)
+		case "restore-seed":
+			resp, jsonError = handleRestoreSeed(params)
+		case "recover-addresses":
+			resp, jsonError = handleRecoverAddresses(params)
 		case "transactions":
 			resp, jsonError = handleAllTransactions(params)
 		case "new-transaction":
@@ -291,6 +337,14 @@ func handleV2Request(j *factom.JSON2Request) (*factom.JSON2Response, *factom.JSO
 			resp, jsonError = handleRemoveIdentityKey(params)
 		case "active-identity-keys":
 			resp, jsonError = handleActiveIdentityKeys(params)
+		case "add-identity":
+			resp, jsonError = handleAddIdentity(params)
+		case "identities":
+			resp, jsonError = handleIdentities(params)
+		case "refresh-identities":
+			resp, jsonError = handleRefreshIdentities(params)
+		case "remove-identity":
+			resp, jsonError = handleRemoveIdentity(params)
 		case "compose-identity-chain":
 			resp, jsonError = handleComposeIdentityChain(params)
 		case "compose-identity-key-replacement":
@@ -312,7 +366,9 @@ func handleV2Request(j *factom.JSON2Request) (*factom.JSON2Response, *factom.JSO
 
 	// don't print password attempts or private keys to output
 	switch j.Method {
-	case "import-addresses", "import-koinify", "unlock-wallet":
+	case "import-addresses", "import-koinify", "unlock-wallet",
+		"import-identity-keys", "export-encrypted-keys", "wallet-backup", "restore-seed-shares",
+		"restore-seed":
 		fmt.Printf("API V2 method: <%v>\n", j.Method)
 	default:
 		fmt.Printf("API V2 method: <%v>  parameters: %s\n", j.Method, params)
@@ -349,6 +405,28 @@ func handleWalletBalances(params []byte) (interface{}, *factom.JSONError) {
 			ecAccounts = append(ecAccounts, mkAddressResponse(e).Public)
 		}
 	}
+	ms, err := fctWallet.GetAllMultisigAddresses()
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	for _, m := range ms {
+		fctAccounts = append(fctAccounts, m.String())
+	}
+
+	// watch-only addresses go after the wallet addresses and are totaled
+	// separately
+	ownFCT, ownEC := len(fctAccounts), len(ecAccounts)
+	ws, err := fctWallet.GetAllWatchOnlyAddresses()
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	for _, w := range ws {
+		if w.IsFactoid() {
+			fctAccounts = append(fctAccounts, w.Address)
+		} else {
+			ecAccounts = append(ecAccounts, w.Address)
+		}
+	}
 
 	var stringOfAccountsEC string
 	if len(ecAccounts) != 0 {
@@ -382,9 +460,11 @@ func handleWalletBalances(params []byte) (interface{}, *factom.JSONError) {
 
 	//Total up the balances
 	var (
-		ackBalTotalEC   int64
-		savedBalTotalEC int64
-		badErrorEC      string
+		ackBalTotalEC       int64
+		savedBalTotalEC     int64
+		ackBalWatchOnlyEC   int64
+		savedBalWatchOnlyEC int64
+		badErrorEC          string
 	)
 
 	var floatType = reflect.TypeOf(int64(0))
@@ -396,11 +476,15 @@ func handleWalletBalances(params []byte) (interface{}, *factom.JSONError) {
 		}
 		v := reflect.ValueOf(x["ack"])
 		covneredAck := v.Convert(floatType)
-		ackBalTotalEC = ackBalTotalEC + covneredAck.Int()
-
 		rawr := reflect.ValueOf(x["saved"])
 		convertedSaved := rawr.Convert(floatType)
-		savedBalTotalEC = savedBalTotalEC + convertedSaved.Int()
+		if i < ownEC {
+			ackBalTotalEC = ackBalTotalEC + covneredAck.Int()
+			savedBalTotalEC = savedBalTotalEC + convertedSaved.Int()
+		} else {
+			ackBalWatchOnlyEC = ackBalWatchOnlyEC + covneredAck.Int()
+			savedBalWatchOnlyEC = savedBalWatchOnlyEC + convertedSaved.Int()
+		}
 
 		errors := x["err"]
 		if errors == "Not fully booted" {
@@ -438,9 +522,11 @@ func handleWalletBalances(params []byte) (interface{}, *factom.JSONError) {
 
 	// Total up the balances
 	var (
-		ackBalTotalFCT   int64
-		savedBalTotalFCT int64
-		badErrorFCT      string
+		ackBalTotalFCT       int64
+		savedBalTotalFCT     int64
+		ackBalWatchOnlyFCT   int64
+		savedBalWatchOnlyFCT int64
+		badErrorFCT          string
 	)
 
 	for i := range respFCT.Result.Balances {
@@ -450,11 +536,15 @@ func handleWalletBalances(params []byte) (interface{}, *factom.JSONError) {
 		}
 		v := reflect.ValueOf(x["ack"])
 		covneredAck := v.Convert(floatType)
-		ackBalTotalFCT = ackBalTotalFCT + covneredAck.Int()
-
 		rawr := reflect.ValueOf(x["saved"])
 		convertedSaved := rawr.Convert(floatType)
-		savedBalTotalFCT = savedBalTotalFCT + convertedSaved.Int()
+		if i < ownFCT {
+			ackBalTotalFCT = ackBalTotalFCT + covneredAck.Int()
+			savedBalTotalFCT = savedBalTotalFCT + convertedSaved.Int()
+		} else {
+			ackBalWatchOnlyFCT = ackBalWatchOnlyFCT + covneredAck.Int()
+			savedBalWatchOnlyFCT = savedBalWatchOnlyFCT + convertedSaved.Int()
+		}
 
 		errors := x["err"]
 		if errors == "Not fully booted" {
@@ -486,6 +576,10 @@ func handleWalletBalances(params []byte) (interface{}, *factom.JSONError) {
 	resp.FactoidAccountBalances.Saved = savedBalTotalFCT
 	resp.EntryCreditAccountBalances.Ack = ackBalTotalEC
 	resp.EntryCreditAccountBalances.Saved = savedBalTotalEC
+	resp.WatchOnlyFactoidBalances.Ack = ackBalWatchOnlyFCT
+	resp.WatchOnlyFactoidBalances.Saved = savedBalWatchOnlyFCT
+	resp.WatchOnlyEntryCreditBalances.Ack = ackBalWatchOnlyEC
+	resp.WatchOnlyEntryCreditBalances.Saved = savedBalWatchOnlyEC
 
 	return resp, nil
 }
@@ -545,10 +639,24 @@ func handleAllAddresses(params []byte) (interface{}, *factom.JSONError) {
 		return nil, newCustomInternalError(err.Error())
 	}
 	for _, f := range fs {
-		resp.Addresses = append(resp.Addresses, mkAddressResponse(f))
+		a := mkAddressResponse(f)
+		a.Label = fctWallet.LookupLabel(a.Public)
+		resp.Addresses = append(resp.Addresses, a)
 	}
 	for _, e := range es {
-		resp.Addresses = append(resp.Addresses, mkAddressResponse(e))
+		a := mkAddressResponse(e)
+		a.Label = fctWallet.LookupLabel(a.Public)
+		resp.Addresses = append(resp.Addresses, a)
+	}
+
+	ms, err := fctWallet.GetAllMultisigAddresses()
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	for _, m := range ms {
+		a := mkMultisigResponse(m)
+		a.Label = fctWallet.LookupLabel(a.Public)
+		resp.Addresses = append(resp.Addresses, a)
 	}
 
 	return resp, nil
@@ -584,6 +692,18 @@ func handleImportAddresses(params []byte) (interface{}, *factom.JSONError) {
 
 	resp := new(multiAddressResponse)
 	for _, v := range req.Addresses {
+		switch factom.EncryptedKeyStringType(v.Secret) {
+		case factom.FactoidSecEncrypted, factom.ECSecEncrypted:
+			pub, err := fctWallet.ImportEncryptedKey(v.Secret, req.Password)
+			if err != nil {
+				return nil, newCustomInternalError(err.Error())
+			}
+			// the secret is only returned in its encrypted form
+			a := &addressResponse{Public: pub, Secret: v.Secret}
+			resp.Addresses = append(resp.Addresses, a)
+			continue
+		}
+
 		switch factom.AddressStringType(v.Secret) {
 		case factom.FactoidSec:
 			f, err := factom.GetFactoidAddress(v.Secret)
@@ -612,6 +732,224 @@ func handleImportAddresses(params []byte) (interface{}, *factom.JSONError) {
 	return resp, nil
 }
 
+func handleImportMultisigAddress(params []byte) (interface{}, *factom.JSONError) {
+	req := new(importMultisigRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	r, err := fctWallet.ImportMultisigAddress(req.Required, req.Members...)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	return mkMultisigResponse(r), nil
+}
+
+func handleMultisigAddresses(params []byte) (interface{}, *factom.JSONError) {
+	ms, err := fctWallet.GetAllMultisigAddresses()
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(multiAddressResponse)
+	for _, m := range ms {
+		a := mkMultisigResponse(m)
+		a.Label = fctWallet.LookupLabel(a.Public)
+		resp.Addresses = append(resp.Addresses, a)
+	}
+	return resp, nil
+}
+
+func handleMultisigSignatures(params []byte) (interface{}, *factom.JSONError) {
+	req := new(multisigSignaturesRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	sigs, err := fctWallet.GetMultisigSignatures(req.Name, req.Address)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	return &multisigSignaturesResponse{Signatures: sigs}, nil
+}
+
+func handleAddMultisigSignatures(params []byte) (interface{}, *factom.JSONError) {
+	req := new(multisigSignaturesRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+	if len(req.Signatures) == 0 {
+		return nil, newCustomInvalidParamsError("at least one signature is required")
+	}
+
+	if err := fctWallet.AddMultisigSignatures(req.Name, req.Address, req.Signatures...); err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	sigs, err := fctWallet.GetMultisigSignatures(req.Name, req.Address)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	return &multisigSignaturesResponse{Signatures: sigs}, nil
+}
+
+func handleAddSigner(params []byte) (interface{}, *factom.JSONError) {
+	req := new(addSignerRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+	if req.Socket == "" {
+		return nil, newCustomInvalidParamsError("socket path is required")
+	}
+	// anyone who can call the wallet api could otherwise have the wallet sign
+	// with a daemon run by another user, so only private sockets of the user
+	// running the wallet are accepted
+	if err := factom.CheckSignerSocket(req.Socket); err != nil {
+		return nil, newCustomInvalidParamsError(err.Error())
+	}
+
+	signers, err := factom.DialSigners(req.Socket)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(signersResponse)
+	for _, s := range signers {
+		fctWallet.AddSigner(s)
+		resp.Signers = append(resp.Signers, factom.NewSignerKey(s))
+	}
+	return resp, nil
+}
+
+func handleSigners(params []byte) (interface{}, *factom.JSONError) {
+	resp := new(signersResponse)
+	for _, s := range fctWallet.GetSigners() {
+		resp.Signers = append(resp.Signers, factom.NewSignerKey(s))
+	}
+	return resp, nil
+}
+
+func handleImportWatchOnlyAddresses(params []byte) (interface{}, *factom.JSONError) {
+	req := new(importWatchOnlyRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	resp := new(multiWatchOnlyResponse)
+	for _, v := range req.Addresses {
+		a, err := fctWallet.ImportWatchOnlyAddress(v.Address, v.Label)
+		if err != nil {
+			return nil, newCustomInternalError(err.Error())
+		}
+		resp.Addresses = append(resp.Addresses, a)
+	}
+	return resp, nil
+}
+
+func handleWatchOnlyAddresses(params []byte) (interface{}, *factom.JSONError) {
+	as, err := fctWallet.GetAllWatchOnlyAddresses()
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(multiWatchOnlyResponse)
+	resp.Addresses = as
+	return resp, nil
+}
+
+func handleSetLabel(params []byte) (interface{}, *factom.JSONError) {
+	req := new(factom.AddressLabel)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	l, err := fctWallet.SetLabel(req.Address, req.Label, req.Tags, req.Notes)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	return l, nil
+}
+
+func handleLabels(params []byte) (interface{}, *factom.JSONError) {
+	ls, err := fctWallet.GetAllLabels()
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(multiLabelResponse)
+	resp.Labels = ls
+	return resp, nil
+}
+
+func handleRemoveLabel(params []byte) (interface{}, *factom.JSONError) {
+	req := new(addressRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	if err := fctWallet.RemoveLabel(req.Address); err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(simpleResponse)
+	resp.Success = true
+	return resp, nil
+}
+
+func handleRestoreLabels(params []byte) (interface{}, *factom.JSONError) {
+	req := new(restoreLabelsRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	skipped, err := fctWallet.RestoreLabels(req.Labels, req.Contacts)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(restoreLabelsResponse)
+	resp.Skipped = skipped
+	return resp, nil
+}
+
+func handleAddContact(params []byte) (interface{}, *factom.JSONError) {
+	req := new(factom.AddressLabel)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	l, err := fctWallet.AddContact(req.Address, req.Label, req.Tags, req.Notes)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	return l, nil
+}
+
+func handleContacts(params []byte) (interface{}, *factom.JSONError) {
+	ls, err := fctWallet.GetAllContacts()
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(multiLabelResponse)
+	resp.Labels = ls
+	return resp, nil
+}
+
+func handleRemoveContact(params []byte) (interface{}, *factom.JSONError) {
+	req := new(addressRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	if err := fctWallet.RemoveContact(req.Address); err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(simpleResponse)
+	resp.Success = true
+	return resp, nil
+}
+
 func handleImportKoinify(params []byte) (interface{}, *factom.JSONError) {
 	req := new(importKoinifyRequest)
 	if err := json.Unmarshal(params, req); err != nil {
@@ -630,12 +968,35 @@ func handleImportKoinify(params []byte) (interface{}, *factom.JSONError) {
 }
 
 func handleWalletBackup(params []byte) (interface{}, *factom.JSONError) {
+	req := new(walletBackupRequest)
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, req); err != nil {
+			return nil, newInvalidParamsError()
+		}
+	}
+
 	resp := new(walletBackupResponse)
 
-	if seed, err := fctWallet.GetSeed(); err != nil {
+	// there is no encrypted format for the seed and its passphrase, so they
+	// are left out of password protected backups. The seed can be backed up
+	// with wallet-backup-shares instead.
+	if req.Password == "" {
+		if seed, err := fctWallet.GetSeed(); err != nil {
+			return nil, newCustomInternalError(err.Error())
+		} else {
+			resp.Seed = seed
+		}
+	}
+
+	if opts, err := fctWallet.GetDerivationOptions(); err != nil {
 		return nil, newCustomInternalError(err.Error())
 	} else {
-		resp.Seed = seed
+		if req.Password == "" {
+			resp.Passphrase = opts.Passphrase
+		}
+		resp.FactoidPath = opts.FactoidPath.String()
+		resp.ECPath = opts.ECPath.String()
+		resp.IdentityPath = opts.IdentityPath.String()
 	}
 
 	fs, es, err := fctWallet.GetAllAddresses()
@@ -651,6 +1012,14 @@ func handleWalletBackup(params []byte) (interface{}, *factom.JSONError) {
 		resp.Addresses = append(resp.Addresses, a)
 	}
 
+	ms, err := fctWallet.GetAllMultisigAddresses()
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	for _, m := range ms {
+		resp.Multisig = append(resp.Multisig, mkMultisigResponse(m))
+	}
+
 	idKeys, err := fctWallet.GetAllIdentityKeys()
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
@@ -662,6 +1031,182 @@ func handleWalletBackup(params []byte) (interface{}, *factom.JSONError) {
 		resp.IdentityKeys = append(resp.IdentityKeys, keyResp)
 	}
 
+	// with a password the individual secret keys are only returned encrypted
+	if req.Password != "" {
+		for _, a := range resp.Addresses {
+			if a.Secret, err = fctWallet.ExportEncryptedKey(a.Public, req.Password); err != nil {
+				return nil, newCustomInternalError(err.Error())
+			}
+		}
+		for _, k := range resp.IdentityKeys {
+			if k.Secret, err = fctWallet.ExportEncryptedKey(k.Public, req.Password); err != nil {
+				return nil, newCustomInternalError(err.Error())
+			}
+		}
+	}
+
+	if resp.Labels, err = fctWallet.GetAllLabels(); err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	if resp.Contacts, err = fctWallet.GetAllContacts(); err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	return resp, nil
+}
+
+func handleSignData(params []byte) (interface{}, *factom.JSONError) {
+	req := new(signDataRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	sig, err := fctWallet.SignData(req.Signer, req.Data)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	return sig, nil
+}
+
+func handleVerifyData(params []byte) (interface{}, *factom.JSONError) {
+	req := new(verifyDataRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	sig := &factom.MessageSignature{PubKey: req.PubKey, Signature: req.Signature}
+	resp := new(verifyDataResponse)
+	if err := factom.VerifyMessage(req.Signer, req.Data, sig); err != nil {
+		resp.Error = err.Error()
+	} else {
+		resp.Valid = true
+	}
+	return resp, nil
+}
+
+func handleWalletBackupShares(params []byte) (interface{}, *factom.JSONError) {
+	req := new(seedSharesRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	shares, err := fctWallet.SplitSeed(req.Threshold, req.Shares)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(seedSharesResponse)
+	resp.Shares = shares
+	return resp, nil
+}
+
+func handleRestoreSeedShares(params []byte) (interface{}, *factom.JSONError) {
+	req := new(restoreSeedSharesRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+	opts, err := req.options()
+	if err != nil {
+		return nil, newCustomInvalidParamsError(err.Error())
+	}
+
+	if err := fctWallet.RestoreSeedFromShares(opts, req.Shares...); err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	if _, err := fctWallet.RecoverAddresses(req.GapLimit); err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(simpleResponse)
+	resp.Success = true
+	return resp, nil
+}
+
+func handleRestoreSeed(params []byte) (interface{}, *factom.JSONError) {
+	req := new(restoreSeedRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+	opts, err := req.options()
+	if err != nil {
+		return nil, newCustomInvalidParamsError(err.Error())
+	}
+
+	if err := fctWallet.RestoreSeed(req.Mnemonic, opts); err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	if _, err := fctWallet.RecoverAddresses(req.GapLimit); err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(simpleResponse)
+	resp.Success = true
+	return resp, nil
+}
+
+func handleRecoverAddresses(params []byte) (interface{}, *factom.JSONError) {
+	req := new(recoverAddressesRequest)
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, req); err != nil {
+			return nil, newInvalidParamsError()
+		}
+	}
+
+	r, err := fctWallet.RecoverAddresses(req.GapLimit)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(recoverAddressesResponse)
+	for _, f := range r.FCTAddresses {
+		resp.Addresses = append(resp.Addresses, mkAddressResponse(f))
+	}
+	for _, e := range r.ECAddresses {
+		resp.Addresses = append(resp.Addresses, mkAddressResponse(e))
+	}
+	resp.NextFactoidAddressIndex = r.NextFactoidAddressIndex
+	resp.NextECAddressIndex = r.NextECAddressIndex
+	return resp, nil
+}
+
+func handleExportEncryptedKeys(params []byte) (interface{}, *factom.JSONError) {
+	req := new(exportEncryptedKeysRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+	if req.Password == "" {
+		return nil, newCustomInvalidParamsError("password is required")
+	}
+
+	pubs := req.Addresses
+	if len(pubs) == 0 {
+		fs, es, err := fctWallet.GetAllAddresses()
+		if err != nil {
+			return nil, newCustomInternalError(err.Error())
+		}
+		for _, f := range fs {
+			pubs = append(pubs, f.String())
+		}
+		for _, e := range es {
+			pubs = append(pubs, e.String())
+		}
+		ks, err := fctWallet.GetAllIdentityKeys()
+		if err != nil {
+			return nil, newCustomInternalError(err.Error())
+		}
+		for _, k := range ks {
+			pubs = append(pubs, k.String())
+		}
+	}
+
+	resp := new(multiAddressResponse)
+	for _, pub := range pubs {
+		sec, err := fctWallet.ExportEncryptedKey(pub, req.Password)
+		if err != nil {
+			return nil, newCustomInternalError(err.Error())
+		}
+		resp.Addresses = append(resp.Addresses, &addressResponse{Public: pub, Secret: sec})
+	}
 	return resp, nil
 }
 
@@ -720,6 +1265,18 @@ func handleAllTransactions(params []byte) (interface{}, *factom.JSONError) {
 			}
 			resp.Transactions = append(resp.Transactions, r)
 		}
+	case req.WatchOnly:
+		txs, err := fctWallet.GetWatchOnlyTXs()
+		if err != nil {
+			return nil, newCustomInternalError(err.Error())
+		}
+		for _, tx := range txs {
+			r, err := factoidTxToTransaction(tx)
+			if err != nil {
+				return nil, newCustomInternalError(err.Error())
+			}
+			resp.Transactions = append(resp.Transactions, r)
+		}
 	case req.Range.End != 0:
 		txs, err := fctWallet.TXDB().GetTXRange(req.Range.Start, req.Range.End)
 		if err != nil {
@@ -975,7 +1532,7 @@ func handleComposeChain(params []byte) (interface{}, *factom.JSONError) {
 	ecpub := req.ECPub
 	force := req.Force
 
-	ec, err := fctWallet.GetECAddress(ecpub)
+	ec, err := fctWallet.GetECSigner(ecpub)
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1001,7 +1558,7 @@ func handleComposeChain(params []byte) (interface{}, *factom.JSONError) {
 		}
 	}
 
-	commit, err := factom.ComposeChainCommit(c, ec)
+	commit, err := factom.ComposeChainCommitWithSigner(c, ec, time.Now())
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1027,7 +1584,19 @@ func handleComposeEntry(params []byte) (interface{}, *factom.JSONError) {
 	ecpub := req.ECPub
 	force := req.Force
 
-	ec, err := fctWallet.GetECAddress(ecpub)
+	if req.SignerKey != "" {
+		signerKey, err := fctWallet.GetIdentitySigner(req.SignerKey)
+		if err != nil || signerKey == nil {
+			return nil, newCustomInternalError("Wallet: failed to fetch signerkey from given identity public key")
+		}
+		s, err := factom.NewSignedEntryWithSigner(&e, signerKey, req.SignerChainID)
+		if err != nil {
+			return nil, newCustomInternalError(err.Error())
+		}
+		e = *s
+	}
+
+	ec, err := fctWallet.GetECSigner(ecpub)
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1052,7 +1621,7 @@ func handleComposeEntry(params []byte) (interface{}, *factom.JSONError) {
 		}
 	}
 
-	commit, err := factom.ComposeEntryCommit(&e, ec)
+	commit, err := factom.ComposeEntryCommitWithSigner(&e, ec, time.Now())
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1138,6 +1707,18 @@ func handleImportIdentityKeys(params []byte) (interface{}, *factom.JSONError) {
 
 	resp := new(multiIdentityKeyResponse)
 	for _, v := range req.Keys {
+		if factom.EncryptedKeyStringType(v.Secret) == factom.IDSecEncrypted {
+			pub, err := fctWallet.ImportEncryptedKey(v.Secret, req.Password)
+			if err != nil {
+				return nil, newCustomInternalError(err.Error())
+			}
+			keyResp := new(identityKeyResponse)
+			keyResp.Public = pub
+			keyResp.Secret = v.Secret
+			resp.Keys = append(resp.Keys, keyResp)
+			continue
+		}
+
 		key, err := factom.GetIdentityKey(v.Secret)
 		if err != nil {
 			return nil, newCustomInternalError(err.Error())
@@ -1191,7 +1772,7 @@ func handleActiveIdentityKeys(params []byte) (interface{}, *factom.JSONError) {
 	resp.ChainID = req.ChainID
 
 	if req.Height == nil {
-		keys, currentHeight, err := factom.GetActiveIdentityKeys(req.ChainID)
+		keys, currentHeight, err := idCache.GetActiveIdentityKeys(req.ChainID)
 		if err != nil {
 			return nil, newCustomInternalError(fmt.Sprintf("ActiveIdentityKeys: %s", err.Error()))
 		}
@@ -1200,7 +1781,7 @@ func handleActiveIdentityKeys(params []byte) (interface{}, *factom.JSONError) {
 		return resp, nil
 	}
 
-	keys, err := factom.GetActiveIdentityKeysAtHeight(req.ChainID, *req.Height)
+	keys, err := idCache.GetActiveIdentityKeysAtHeight(req.ChainID, *req.Height)
 	if err != nil {
 		return nil, newCustomInternalError(fmt.Sprintf("ActiveIdentityKeys: %s", err.Error()))
 	}
@@ -1209,6 +1790,72 @@ func handleActiveIdentityKeys(params []byte) (interface{}, *factom.JSONError) {
 	return resp, nil
 }
 
+func handleAddIdentity(params []byte) (interface{}, *factom.JSONError) {
+	req := new(walletIdentityRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	i, err := fctWallet.AddIdentity(req.ChainID)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+	return i, nil
+}
+
+func handleIdentities(params []byte) (interface{}, *factom.JSONError) {
+	is, err := fctWallet.GetAllWalletIdentities()
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(walletIdentitiesResponse)
+	resp.Identities = is
+	return resp, nil
+}
+
+func handleRefreshIdentities(params []byte) (interface{}, *factom.JSONError) {
+	is, err := fctWallet.RefreshIdentities()
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(walletIdentitiesResponse)
+	resp.Identities = is
+	return resp, nil
+}
+
+func handleRemoveIdentity(params []byte) (interface{}, *factom.JSONError) {
+	req := new(walletIdentityRequest)
+	if err := json.Unmarshal(params, req); err != nil {
+		return nil, newInvalidParamsError()
+	}
+
+	if err := fctWallet.RemoveWalletIdentity(req.ChainID); err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
+
+	resp := new(simpleResponse)
+	resp.Success = true
+	return resp, nil
+}
+
+// identitySignerKey picks the key of an identity controlled by the wallet to
+// sign with; the highest priority key that may replace oldKey, or any active
+// key if oldKey is empty. The identity is refreshed from the blockchain first
+// unless skipRefresh is set.
+func identitySignerKey(chainID, oldKey string, skipRefresh bool) (string, error) {
+	if !skipRefresh {
+		if _, err := fctWallet.RefreshIdentity(chainID); err != nil {
+			return "", err
+		}
+	}
+	if oldKey != "" {
+		return fctWallet.GetIdentityReplacementSignerKey(chainID, oldKey)
+	}
+	return fctWallet.GetIdentitySignerKey(chainID, -1)
+}
+
 func handleComposeIdentityChain(params []byte) (interface{}, *factom.JSONError) {
 	req := new(identityChainRequest)
 	if err := json.Unmarshal(params, req); err != nil {
@@ -1216,7 +1863,7 @@ func handleComposeIdentityChain(params []byte) (interface{}, *factom.JSONError)
 	}
 
 	ecpub := req.ECPub
-	ec, err := fctWallet.GetECAddress(ecpub)
+	ec, err := fctWallet.GetECSigner(ecpub)
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1246,7 +1893,7 @@ func handleComposeIdentityChain(params []byte) (interface{}, *factom.JSONError)
 		}
 	}
 
-	commit, err := factom.ComposeChainCommit(c, ec)
+	commit, err := factom.ComposeChainCommitWithSigner(c, ec, time.Now())
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1268,13 +1915,21 @@ func handleComposeIdentityKeyReplacement(params []byte) (interface{}, *factom.JS
 		return nil, newInvalidParamsError()
 	}
 
-	signerKey, err := fctWallet.GetIdentityKey(req.SignerKey)
+	if req.SignerKey == "" {
+		key, err := identitySignerKey(req.ChainID, req.OldKey, req.SkipRefresh)
+		if err != nil {
+			return nil, newCustomInternalError(err.Error())
+		}
+		req.SignerKey = key
+	}
+
+	signerKey, err := fctWallet.GetIdentitySigner(req.SignerKey)
 	if err != nil || signerKey == nil {
 		return nil, newCustomInternalError("Wallet: failed to fetch signerkey from given identity public key")
 	}
 
 	ecpub := req.ECPub
-	ec, err := fctWallet.GetECAddress(ecpub)
+	ec, err := fctWallet.GetECSigner(ecpub)
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1282,7 +1937,7 @@ func handleComposeIdentityKeyReplacement(params []byte) (interface{}, *factom.JS
 		return nil, newCustomInternalError("Wallet: entry credit address not found")
 	}
 
-	e, err := factom.NewIdentityKeyReplacementEntry(req.ChainID, req.OldKey, req.NewKey, signerKey)
+	e, err := factom.NewIdentityKeyReplacementEntryWithSigner(req.ChainID, req.OldKey, req.NewKey, signerKey)
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1304,7 +1959,7 @@ func handleComposeIdentityKeyReplacement(params []byte) (interface{}, *factom.JS
 		}
 	}
 
-	commit, err := factom.ComposeEntryCommit(e, ec)
+	commit, err := factom.ComposeEntryCommitWithSigner(e, ec, time.Now())
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1326,7 +1981,15 @@ func handleComposeIdentityAttribute(params []byte) (interface{}, *factom.JSONErr
 		return nil, newInvalidParamsError()
 	}
 
-	signerKey, err := fctWallet.GetIdentityKey(req.SignerKey)
+	if req.SignerKey == "" {
+		key, err := identitySignerKey(req.SignerChainID, "", req.SkipRefresh)
+		if err != nil {
+			return nil, newCustomInternalError(err.Error())
+		}
+		req.SignerKey = key
+	}
+
+	signerKey, err := fctWallet.GetIdentitySigner(req.SignerKey)
 	if err != nil || signerKey == nil {
 		return nil, newCustomInternalError("Wallet: failed to fetch signerkey from given identity public key")
 	}
@@ -1344,11 +2007,14 @@ func handleComposeIdentityAttribute(params []byte) (interface{}, *factom.JSONErr
 		return nil, newCustomInternalError(err.Error())
 	}
 
-	e := factom.NewIdentityAttributeEntry(req.ReceiverChainID, req.DestinationChainID, string(attributesJSON), signerKey, req.SignerChainID)
+	e, err := factom.NewIdentityAttributeEntryWithSigner(req.ReceiverChainID, req.DestinationChainID, string(attributesJSON), signerKey, req.SignerChainID)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
 	ecpub := req.ECPub
 	force := req.Force
 
-	ec, err := fctWallet.GetECAddress(ecpub)
+	ec, err := fctWallet.GetECSigner(ecpub)
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1373,7 +2039,7 @@ func handleComposeIdentityAttribute(params []byte) (interface{}, *factom.JSONErr
 		}
 	}
 
-	commit, err := factom.ComposeEntryCommit(e, ec)
+	commit, err := factom.ComposeEntryCommitWithSigner(e, ec, time.Now())
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1395,16 +2061,27 @@ func handleComposeIdentityAttributeEndorsement(params []byte) (interface{}, *fac
 		return nil, newInvalidParamsError()
 	}
 
-	signerKey, err := fctWallet.GetIdentityKey(req.SignerKey)
+	if req.SignerKey == "" {
+		key, err := identitySignerKey(req.SignerChainID, "", req.SkipRefresh)
+		if err != nil {
+			return nil, newCustomInternalError(err.Error())
+		}
+		req.SignerKey = key
+	}
+
+	signerKey, err := fctWallet.GetIdentitySigner(req.SignerKey)
 	if err != nil || signerKey == nil {
 		return nil, newCustomInternalError("Wallet: failed to fetch signerkey from given identity public key")
 	}
 
-	e := factom.NewIdentityAttributeEndorsementEntry(req.DestinationChainID, req.EntryHash, signerKey, req.SignerChainID)
+	e, err := factom.NewIdentityAttributeEndorsementEntryWithSigner(req.DestinationChainID, req.EntryHash, signerKey, req.SignerChainID)
+	if err != nil {
+		return nil, newCustomInternalError(err.Error())
+	}
 	ecpub := req.ECPub
 	force := req.Force
 
-	ec, err := fctWallet.GetECAddress(ecpub)
+	ec, err := fctWallet.GetECSigner(ecpub)
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1429,7 +2106,7 @@ func handleComposeIdentityAttributeEndorsement(params []byte) (interface{}, *fac
 		}
 	}
 
-	commit, err := factom.ComposeEntryCommit(e, ec)
+	commit, err := factom.ComposeEntryCommitWithSigner(e, ec, time.Now())
 	if err != nil {
 		return nil, newCustomInternalError(err.Error())
 	}
@@ -1498,6 +2175,40 @@ func mkAddressResponse(a addressResponder) *addressResponse {
 	return r
 }
 
+// options returns the wallet derivation options of the request.
+func (r *derivationRequest) options() (*factom.DerivationOptions, error) {
+	var err error
+	opts := new(factom.DerivationOptions)
+	opts.Passphrase = r.Passphrase
+	if opts.FactoidPath, err = parseOptionalPath(r.FactoidPath); err != nil {
+		return nil, err
+	}
+	if opts.ECPath, err = parseOptionalPath(r.ECPath); err != nil {
+		return nil, err
+	}
+	if opts.IdentityPath, err = parseOptionalPath(r.IdentityPath); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// parseOptionalPath parses a derivation path, or returns nil for the default
+// path if s is empty.
+func parseOptionalPath(s string) (*factom.DerivationPath, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return factom.ParseDerivationPath(s)
+}
+
+func mkMultisigResponse(r *factom.RCD2) *addressResponse {
+	a := new(addressResponse)
+	a.Public = r.String()
+	a.Required = r.M
+	a.Members = r.MemberStrings()
+	return a
+}
+
 func factoidTxToTransaction(t interfaces.ITransaction) (
 	*factom.Transaction,
 	error,
@@ -1536,6 +2247,7 @@ func factoidTxToTransaction(t interfaces.ITransaction) (
 		tmp := new(factom.TransAddress)
 		tmp.Address = primitives.ConvertFctAddressToUserStr(v.GetAddress())
 		tmp.Amount = v.GetAmount()
+		tmp.Label = fctWallet.LookupLabel(tmp.Address)
 		r.Inputs = append(r.Inputs, tmp)
 	}
 
@@ -1543,6 +2255,7 @@ func factoidTxToTransaction(t interfaces.ITransaction) (
 		tmp := new(factom.TransAddress)
 		tmp.Address = primitives.ConvertFctAddressToUserStr(v.GetAddress())
 		tmp.Amount = v.GetAmount()
+		tmp.Label = fctWallet.LookupLabel(tmp.Address)
 		r.Outputs = append(r.Outputs, tmp)
 	}
 
@@ -1550,6 +2263,7 @@ func factoidTxToTransaction(t interfaces.ITransaction) (
 		tmp := new(factom.TransAddress)
 		tmp.Address = primitives.ConvertECAddressToUserStr(v.GetAddress())
 		tmp.Amount = v.GetAmount()
+		tmp.Label = fctWallet.LookupLabel(tmp.Address)
 		r.ECOutputs = append(r.ECOutputs, tmp)
 	}
 
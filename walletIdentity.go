@@ -0,0 +1,156 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// WalletIdentity is an identity controlled by the wallet: the identity chain,
+// its name, and its active Identity Keys in priority order as of Height, with
+// whether the wallet holds the secret for each of them.
+type WalletIdentity struct {
+	ChainID string               `json:"chainid"`
+	Name    []string             `json:"name"`
+	Keys    []*WalletIdentityKey `json:"keys"`
+	Height  int64                `json:"height"`
+}
+
+// WalletIdentityKey is an active Identity Key of a WalletIdentity. Level 0 is
+// the highest priority key.
+type WalletIdentityKey struct {
+	Level    int    `json:"level"`
+	Key      string `json:"key"`
+	InWallet bool   `json:"inwallet"`
+}
+
+// Level returns the priority level of the active key, or -1 if the key is not
+// active for the identity.
+func (i *WalletIdentity) Level(key string) int {
+	for _, k := range i.Keys {
+		if k.Key == key {
+			return k.Level
+		}
+	}
+	return -1
+}
+
+// SignerKey returns the highest priority key held by the wallet with a level of
+// at most maxLevel, or an empty string if the wallet holds no such key. Use a
+// negative maxLevel for any level.
+func (i *WalletIdentity) SignerKey(maxLevel int) string {
+	for _, k := range i.Keys {
+		if maxLevel >= 0 && k.Level > maxLevel {
+			break
+		}
+		if k.InWallet {
+			return k.Key
+		}
+	}
+	return ""
+}
+
+func (i *WalletIdentity) MarshalBinary() ([]byte, error) {
+	return json.Marshal(i)
+}
+
+func (i *WalletIdentity) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, i)
+}
+
+func (i *WalletIdentity) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	return nil, i.UnmarshalBinary(data)
+}
+
+func (i *WalletIdentity) String() string {
+	var s string
+	s += fmt.Sprintln("ChainID:", i.ChainID)
+	s += fmt.Sprintln("Name:", strings.Join(i.Name, ", "))
+	s += fmt.Sprintln("Height:", i.Height)
+	for _, k := range i.Keys {
+		held := ""
+		if k.InWallet {
+			held = " (in wallet)"
+		}
+		s += fmt.Sprintf("Key %d: %s%s\n", k.Level, k.Key, held)
+	}
+	return s
+}
+
+// AddWalletIdentity adds the identity to the identities controlled by the
+// wallet. The wallet must hold at least one of its active keys.
+func AddWalletIdentity(chainID string) (*WalletIdentity, error) {
+	params := new(struct {
+		ChainID string `json:"chainid"`
+	})
+	params.ChainID = chainID
+
+	req := NewJSON2Request("add-identity", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(WalletIdentity)
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// FetchWalletIdentities returns the identities controlled by the wallet.
+func FetchWalletIdentities() ([]*WalletIdentity, error) {
+	return walletIdentitiesRequest("identities")
+}
+
+// RefreshWalletIdentities updates the keys of the identities controlled by the
+// wallet from the blockchain and returns them.
+func RefreshWalletIdentities() ([]*WalletIdentity, error) {
+	return walletIdentitiesRequest("refresh-identities")
+}
+
+// RemoveWalletIdentity removes the identity from the identities controlled by
+// the wallet. The Identity Keys are kept.
+func RemoveWalletIdentity(chainID string) error {
+	params := new(struct {
+		ChainID string `json:"chainid"`
+	})
+	params.ChainID = chainID
+
+	req := NewJSON2Request("remove-identity", APICounter(), params)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	return nil
+}
+
+func walletIdentitiesRequest(method string) ([]*WalletIdentity, error) {
+	req := NewJSON2Request(method, APICounter(), nil)
+	resp, err := walletRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	r := new(struct {
+		Identities []*WalletIdentity `json:"identities"`
+	})
+	if err := json.Unmarshal(resp.JSONResult(), r); err != nil {
+		return nil, err
+	}
+	return r.Identities, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestWalletIdentitySignerKey(t *testing.T) {
+	i := &WalletIdentity{
+		Keys: []*WalletIdentityKey{
+			{Level: 0, Key: "idpub1", InWallet: false},
+			{Level: 1, Key: "idpub2", InWallet: true},
+			{Level: 2, Key: "idpub3", InWallet: true},
+		},
+	}
+
+	tests := []struct {
+		maxLevel int
+		key      string
+	}{
+		{-1, "idpub2"},
+		{0, ""},
+		{1, "idpub2"},
+		{2, "idpub2"},
+	}
+	for _, tt := range tests {
+		if k := i.SignerKey(tt.maxLevel); k != tt.key {
+			t.Errorf("level %d: expected %q, found %q", tt.maxLevel, tt.key, k)
+		}
+	}
+	if i.Level("idpub3") != 2 || i.Level("idpub4") != -1 {
+		t.Error("unexpected key levels")
+	}
+}
+
+func TestFetchWalletIdentities(t *testing.T) {
+	simlatedWalletResponse := `{
+  "jsonrpc": "2.0",
+  "id": 0,
+  "result": {
+    "identities": [
+      {
+        "chainid": "3b69dabe22c014af9a9bc9dfa7917ce4602a03579597ddf184d8de56702512ae",
+        "name": ["Wallet", "Test"],
+        "keys": [
+          {"level": 0, "key": "idpub2TWHFrWrJxVEmbeXnMRWeKBdFp7bEByosS1phV1bH7NS99zHF9", "inwallet": false},
+          {"level": 1, "key": "idpub1tkTRwxonwCfsvTkk5enWzbZgQSRpWDYtdzPUnq83AgQtecSgc", "inwallet": true}
+        ],
+        "height": 20
+      }
+    ]
+  }
+}`
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, simlatedWalletResponse)
+	}))
+	defer ts.Close()
+
+	SetWalletServer(ts.URL[7:])
+
+	is, err := FetchWalletIdentities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(is) != 1 {
+		t.Fatalf("expected 1 identity, got %d", len(is))
+	}
+	if is[0].Height != 20 || len(is[0].Keys) != 2 || is[0].SignerKey(-1) != is[0].Keys[1].Key {
+		t.Errorf("unexpected identity %v", is[0])
+	}
+}
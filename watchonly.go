@@ -0,0 +1,58 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// WatchOnlyAddress is a public Factoid or Entry Credit address tracked by the
+// wallet without its secret key. Its balance and transactions can be followed
+// but it cannot be used to sign.
+type WatchOnlyAddress struct {
+	Address string `json:"address"`
+	Label   string `json:"label,omitempty"`
+}
+
+// NewWatchOnlyAddress returns a WatchOnlyAddress for a public Factoid (FA...)
+// or Entry Credit (EC...) address.
+func NewWatchOnlyAddress(address, label string) (*WatchOnlyAddress, error) {
+	switch AddressStringType(address) {
+	case FactoidPub, ECPub:
+	default:
+		return nil, fmt.Errorf("%s is not a public Factoid or Entry Credit address", address)
+	}
+
+	a := new(WatchOnlyAddress)
+	a.Address = address
+	a.Label = label
+	return a, nil
+}
+
+// IsFactoid returns true for watch-only Factoid addresses and false for
+// watch-only Entry Credit addresses.
+func (a *WatchOnlyAddress) IsFactoid() bool {
+	return AddressStringType(a.Address) == FactoidPub
+}
+
+func (a *WatchOnlyAddress) MarshalBinary() ([]byte, error) {
+	return json.Marshal(a)
+}
+
+func (a *WatchOnlyAddress) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, a)
+}
+
+func (a *WatchOnlyAddress) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	return nil, a.UnmarshalBinary(data)
+}
+
+func (a *WatchOnlyAddress) String() string {
+	if a.Label == "" {
+		return a.Address
+	}
+	return fmt.Sprintf("%s %s", a.Address, a.Label)
+}
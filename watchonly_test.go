@@ -0,0 +1,62 @@
+// Copyright 2016 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factom_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/FactomProject/factom"
+)
+
+func TestImportWatchOnlyAddresses(t *testing.T) {
+	fa := "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"
+	ec := "EC3MAHiZyfuEb5fZP2fSp2gXMv8WemhQEUFXyQ2f2HjSkYx7xY1S"
+
+	if _, err := NewWatchOnlyAddress("Fs1KWJrpLdfucvmYwN2nWrwepLn8ercpMbzXshd1g8zyhKXLVLWj", ""); err == nil {
+		t.Error("expected error for a secret address")
+	}
+
+	simlatedWalletResponse := fmt.Sprintf(`{
+  "jsonrpc": "2.0",
+  "id": 0,
+  "result": {
+    "addresses": [
+      {"address": %q, "label": "cold storage"},
+      {"address": %q}
+    ]
+  }
+}`, fa, ec)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, simlatedWalletResponse)
+	}))
+	defer ts.Close()
+
+	SetWalletServer(ts.URL[7:])
+
+	a1, err := NewWatchOnlyAddress(fa, "cold storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := NewWatchOnlyAddress(ec, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	as, err := ImportWatchOnlyAddresses(a1, a2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(as) != 2 {
+		t.Fatalf("expected 2 addresses, got %d", len(as))
+	}
+	if as[0].Label != "cold storage" || !as[0].IsFactoid() || as[1].IsFactoid() {
+		t.Errorf("unexpected watch-only addresses %v", as)
+	}
+}
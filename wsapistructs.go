@@ -64,6 +64,7 @@ func (d *HeightsResponse) String() string {
 
 type importRequest struct {
 	Addresses []secretRequest `json:"addresses"`
+	Password  string          `json:"password,omitempty"`
 }
 
 type importKoinifyRequest struct {